@@ -1,10 +1,12 @@
 package cache
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-per/simpkg/helpers"
@@ -19,6 +21,7 @@ type Cache struct {
 	root       string
 	extension  string
 	filePrefix string
+	writes     sync.WaitGroup
 }
 
 // ICache interface
@@ -105,7 +108,9 @@ func (cache *Cache) Path(cacheName string, addTimestamp ...bool) (string, string
 
 // WriteAsync writes cache file asynchronously
 func (cache *Cache) WriteAsync(cacheName string, data []byte, addTimestamp ...bool) {
+	cache.writes.Add(1)
 	go func() {
+		defer cache.writes.Done()
 		err := cache.Write(cacheName, data, addTimestamp...)
 		if err != nil {
 			std.Error("Could not write cache file %v", err)
@@ -113,6 +118,22 @@ func (cache *Cache) WriteAsync(cacheName string, data []byte, addTimestamp ...bo
 	}()
 }
 
+// Flush waits for async writes until ctx is done
+func (cache *Cache) Flush(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		cache.writes.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // Write writes cache file
 func (cache *Cache) Write(cacheName string, data []byte, addTimestamp ...bool) (err error) {
 	dir, file := cache.Path(cacheName, addTimestamp...)
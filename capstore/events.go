@@ -0,0 +1,42 @@
+package capstore
+
+import "time"
+
+// EventType is pool event type
+type EventType string
+
+const (
+	EventAdded             EventType = "added"
+	EventConsumed          EventType = "consumed"
+	EventExpired           EventType = "expired"
+	EventRejectedDuplicate EventType = "rejected_duplicate"
+	EventRejectedSource    EventType = "rejected_source"
+	EventDiscarded         EventType = "discarded"
+)
+
+// Event struct
+type Event struct {
+	Type     EventType     `json:"type"`
+	Action   string        `json:"action"`
+	Checksum string        `json:"checksum"`
+	Age      time.Duration `json:"age"`
+	Token    *Token        `json:"token,omitempty"`
+	Time     time.Time     `json:"time"`
+}
+
+// newEvent creates new event for token
+func newEvent(eventType EventType, action, checksum string, token *Token) Event {
+	now := time.Now()
+	event := Event{
+		Type:     eventType,
+		Action:   action,
+		Checksum: checksum,
+		Token:    token,
+		Time:     now,
+	}
+	if token != nil && !token.CreatedAt.IsZero() {
+		event.Age = now.Sub(token.CreatedAt)
+	}
+
+	return event
+}
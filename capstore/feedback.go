@@ -0,0 +1,175 @@
+package capstore
+
+import (
+	"time"
+
+	"github.com/go-per/simpkg/i18n"
+)
+
+// SourceStats struct
+type SourceStats struct {
+	Source        string    `json:"source"`
+	Good          int       `json:"good"`
+	Bad           int       `json:"bad"`
+	RejectionRate float64   `json:"rejection_rate"`
+	Quarantined   bool      `json:"quarantined"`
+	QuarantinedAt time.Time `json:"quarantined_at,omitempty"`
+}
+
+// issuedToken is a consumed token waiting for feedback
+type issuedToken struct {
+	source string
+	timer  *time.Timer
+}
+
+// sourceStats keeps source feedback counters
+type sourceStats struct {
+	good          int
+	bad           int
+	quarantined   bool
+	quarantinedAt time.Time
+}
+
+// snapshot returns stats of source counters
+func (s *sourceStats) snapshot(source string) SourceStats {
+	stats := SourceStats{
+		Source:        source,
+		Good:          s.good,
+		Bad:           s.bad,
+		Quarantined:   s.quarantined,
+		QuarantinedAt: s.quarantinedAt,
+	}
+	if total := s.good + s.bad; total > 0 {
+		stats.RejectionRate = float64(s.bad) / float64(total)
+	}
+
+	return stats
+}
+
+// SetQuarantinePolicy quarantine sources whose rejection rate goes over threshold
+// after at least minReports reports, zero threshold disables quarantine
+func (pool *Pool) SetQuarantinePolicy(threshold float64, minReports int) {
+	pool.lk.Lock()
+	pool.quarantineThreshold = threshold
+	pool.quarantineMinReports = minReports
+	pool.lk.Unlock()
+}
+
+// SetFeedbackLifeTime set how long consumed tokens accept feedback
+func (pool *Pool) SetFeedbackLifeTime(t time.Duration) {
+	pool.lk.Lock()
+	pool.feedbackLifeTime = t
+	pool.lk.Unlock()
+}
+
+// OnQuarantine subscribe on source quarantine
+func (pool *Pool) OnQuarantine(fn func(SourceStats)) {
+	pool.lk.Lock()
+	pool.onQuarantineHandlers = append(pool.onQuarantineHandlers, fn)
+	pool.lk.Unlock()
+}
+
+// ReportBad reports consumed token as rejected by target
+func (pool *Pool) ReportBad(id string) error {
+	return pool.report(id, false)
+}
+
+// ReportGood reports consumed token as accepted by target
+func (pool *Pool) ReportGood(id string) error {
+	return pool.report(id, true)
+}
+
+// Sources returns feedback stats per source
+func (pool *Pool) Sources() map[string]SourceStats {
+	pool.lk.RLock()
+	defer pool.lk.RUnlock()
+
+	sources := make(map[string]SourceStats, len(pool.sources))
+	for source, stats := range pool.sources {
+		sources[source] = stats.snapshot(source)
+	}
+
+	return sources
+}
+
+// ReleaseSource removes source from quarantine and reset its counters
+func (pool *Pool) ReleaseSource(source string) {
+	pool.lk.Lock()
+	delete(pool.sources, source)
+	pool.lk.Unlock()
+}
+
+// issue keeps consumed token for feedback, lock must be held by caller
+func (pool *Pool) issue(token *Token) {
+	if pool.feedbackLifeTime <= 0 {
+		return
+	}
+
+	id := token.ID
+	pool.issued[id] = issuedToken{
+		source: token.Source,
+		timer: time.AfterFunc(pool.feedbackLifeTime, func() {
+			pool.lk.Lock()
+			delete(pool.issued, id)
+			pool.lk.Unlock()
+		}),
+	}
+}
+
+// report records token feedback and quarantine its source if needed
+func (pool *Pool) report(id string, good bool) error {
+	pool.lk.Lock()
+	defer pool.lk.Unlock()
+
+	issued, ok := pool.issued[id]
+	if !ok {
+		return i18n.TranslateAsError("token_not_found")
+	}
+	issued.timer.Stop()
+	delete(pool.issued, id)
+
+	stats, ok := pool.sources[issued.source]
+	if !ok {
+		stats = &sourceStats{}
+		pool.sources[issued.source] = stats
+	}
+	if good {
+		stats.good++
+	} else {
+		stats.bad++
+	}
+
+	if !stats.quarantined && pool.shouldQuarantine(stats) {
+		pool.quarantine(issued.source, stats)
+	}
+
+	return nil
+}
+
+// shouldQuarantine determine if source rejection rate is over threshold
+func (pool *Pool) shouldQuarantine(stats *sourceStats) bool {
+	if pool.quarantineThreshold <= 0 || stats.good+stats.bad < pool.quarantineMinReports {
+		return false
+	}
+
+	return stats.snapshot("").RejectionRate > pool.quarantineThreshold
+}
+
+// quarantine discards source tokens and notify subscribers, lock must be held by caller
+func (pool *Pool) quarantine(source string, stats *sourceStats) {
+	stats.quarantined = true
+	stats.quarantinedAt = time.Now()
+
+	for action, tokens := range pool.tokens {
+		for checksum, token := range tokens {
+			if token.Source == source {
+				pool.remove(action, checksum, EventDiscarded)
+			}
+		}
+	}
+
+	snapshot := stats.snapshot(source)
+	for _, fn := range pool.onQuarantineHandlers {
+		go fn(snapshot)
+	}
+}
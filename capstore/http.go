@@ -0,0 +1,223 @@
+package capstore
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-per/simpkg/httpapi"
+	"github.com/go-per/simpkg/identity"
+	"github.com/go-per/simpkg/parse"
+)
+
+// HeaderClientId is http header of identity client id
+const HeaderClientId = "X-Client-Id"
+
+// PushRequest is body of push token request
+type PushRequest struct {
+	Source string `json:"source"`
+	Action string `json:"action"`
+	Value  string `json:"value"`
+	Data   any    `json:"data"`
+}
+
+// Handler serves captcha store over http
+//
+//	GET    /stores                                list stores with tokens length
+//	POST   /stores/{store}/tokens                 push a token, body is PushRequest
+//	GET    /stores/{store}/tokens                 get a token, query: action, wait (e.g. 10s)
+//	POST   /stores/{store}/tokens/{id}/{bad|good} report token feedback
+//	GET    /stores/{store}/len                    tokens length per action
+//	GET    /stores/{store}/stats                  statistics snapshot
+//	DELETE /stores/{store}/stats                  reset statistics
+//	GET    /stores/{store}/sources                feedback stats per source
+//	DELETE /stores/{store}/sources/{source}       release source from quarantine
+type Handler struct {
+	store        *CaptchaStore
+	auth         httpapi.AuthFunc
+	maxWait      time.Duration
+	pollInterval time.Duration
+}
+
+// NewHandler returns new http handler for store, requests are authorized by ui tokens of identity.Instance
+func NewHandler(store *CaptchaStore) *Handler {
+	return &Handler{
+		store:        store,
+		auth:         httpapi.UiTokenAuth(identity.Instance),
+		maxWait:      time.Second * 30,
+		pollInterval: time.Millisecond * 200,
+	}
+}
+
+// ClientIdAuth authorizes requests by registered identity client ids, a client id is not
+// a secret, so it is for trusted networks only
+func ClientIdAuth(i identity.IIdentity) httpapi.AuthFunc {
+	return func(r *http.Request) bool {
+		client := r.Header.Get(HeaderClientId)
+		return client != "" && i.ClientExists(client)
+	}
+}
+
+// SetAuth sets request authorization function, nil allows all requests
+func (h *Handler) SetAuth(fn httpapi.AuthFunc) *Handler {
+	h.auth = fn
+	return h
+}
+
+// SetMaxWait sets maximum long polling duration
+func (h *Handler) SetMaxWait(d time.Duration) *Handler {
+	h.maxWait = d
+	return h
+}
+
+// SetPollInterval sets long polling check interval
+func (h *Handler) SetPollInterval(d time.Duration) *Handler {
+	h.pollInterval = d
+	return h
+}
+
+// ServeHTTP implements http.Handler
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.auth != nil && !h.auth(r) {
+		httpapi.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) == 0 || parts[0] != "stores" {
+		httpapi.Error(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	if len(parts) == 1 {
+		if r.Method != http.MethodGet {
+			httpapi.Error(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		h.listStores(w)
+		return
+	}
+
+	s, ok := h.store.Get(parts[1])
+	if !ok || len(parts) < 3 {
+		httpapi.Error(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	switch {
+	case len(parts) == 5 && parts[2] == "tokens" && r.Method == http.MethodPost:
+		h.report(w, parts[3], parts[4], s.Pool())
+	case len(parts) == 4 && parts[2] == "sources" && r.Method == http.MethodDelete:
+		s.Pool().ReleaseSource(parts[3])
+		w.WriteHeader(http.StatusNoContent)
+	case len(parts) != 3:
+		httpapi.Error(w, http.StatusNotFound, "not found")
+	case parts[2] == "tokens" && r.Method == http.MethodPost:
+		h.push(w, r, s.Pool())
+	case parts[2] == "tokens" && r.Method == http.MethodGet:
+		h.get(w, r, s.Pool())
+	case parts[2] == "len" && r.Method == http.MethodGet:
+		httpapi.JSON(w, http.StatusOK, s.Pool().Len())
+	case parts[2] == "stats" && r.Method == http.MethodGet:
+		httpapi.JSON(w, http.StatusOK, s.Pool().Stats())
+	case parts[2] == "stats" && r.Method == http.MethodDelete:
+		s.Pool().ResetStats()
+		w.WriteHeader(http.StatusNoContent)
+	case parts[2] == "sources" && r.Method == http.MethodGet:
+		httpapi.JSON(w, http.StatusOK, s.Pool().Sources())
+	default:
+		httpapi.Error(w, http.StatusNotFound, "not found")
+	}
+}
+
+// listStores writes stores with tokens length
+func (h *Handler) listStores(w http.ResponseWriter) {
+	stores := make(map[string]interfaceMap)
+	for _, name := range h.store.Names() {
+		s, _ := h.store.Get(name)
+		stores[name] = s.Pool().Len()
+	}
+
+	httpapi.JSON(w, http.StatusOK, stores)
+}
+
+// push adds token to pool
+func (h *Handler) push(w http.ResponseWriter, r *http.Request, pool IPool) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		httpapi.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var request PushRequest
+	if err = parse.Decode(body, &request); err != nil {
+		httpapi.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if request.Value == "" {
+		httpapi.Error(w, http.StatusBadRequest, "token value is empty")
+		return
+	}
+
+	token := pool.PushFrom(request.Source, request.Value, request.Data, request.Action)
+	if token == nil {
+		httpapi.Error(w, http.StatusConflict, "token rejected")
+		return
+	}
+
+	httpapi.JSON(w, http.StatusCreated, token)
+}
+
+// get consumes token from pool, waits for token if requested
+func (h *Handler) get(w http.ResponseWriter, r *http.Request, pool IPool) {
+	query := r.URL.Query()
+	action := query.Get("action")
+
+	var wait time.Duration
+	if v := query.Get("wait"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			httpapi.Error(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		wait = d
+	}
+	if wait > h.maxWait {
+		wait = h.maxWait
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), wait)
+	defer cancel()
+
+	token, err := waitToken(ctx, h.pollInterval, func() (*Token, error) {
+		return pool.Get(action)
+	})
+	if err != nil {
+		httpapi.Error(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	httpapi.JSON(w, http.StatusOK, token)
+}
+
+// report records token feedback
+func (h *Handler) report(w http.ResponseWriter, id, result string, pool IPool) {
+	var err error
+	switch result {
+	case "bad":
+		err = pool.ReportBad(id)
+	case "good":
+		err = pool.ReportGood(id)
+	default:
+		httpapi.Error(w, http.StatusNotFound, "not found")
+		return
+	}
+	if err != nil {
+		httpapi.Error(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
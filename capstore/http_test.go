@@ -0,0 +1,72 @@
+package capstore
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-per/simpkg/identity"
+)
+
+func TestHandler_RemoteStore(t *testing.T) {
+	identity.Instance.AddClient("farm")
+	defer identity.Instance.RemoveClient("farm")
+
+	store := New()
+	store.Use(DefaultStoreKey)
+	server := httptest.NewServer(NewHandler(store).SetPollInterval(time.Millisecond * 10))
+	defer server.Close()
+
+	token, err := identity.Instance.GenerateUiToken("farm")
+	if err != nil {
+		t.Fatalf("GenerateUiToken() error = %v", err)
+	}
+	defer identity.Instance.RemoveUiToken(token)
+	remote := NewRemoteStore(server.URL, DefaultStoreKey).SetUiToken(token)
+	if token := remote.Pool().Push("t1", "data", "login"); token == nil || token.Value != "t1" {
+		t.Fatalf("Push() = %v, want t1", token)
+	}
+	if got := remote.Pool().Len()["login"]; got != 1 {
+		t.Errorf("Len() = %v, want 1", got)
+	}
+
+	go func() {
+		time.Sleep(time.Millisecond * 50)
+		store.Pool().Push("t2", nil, "pay")
+	}()
+	remote.SetWait(time.Second)
+	if token, err := remote.Pool().Get("pay"); err != nil || token.Value != "t2" {
+		t.Errorf("Get() = %v, %v, want t2", token, err)
+	}
+	if got := remote.Pool().Stats().Actions["login"].Produced; got != 1 {
+		t.Errorf("Stats() produced = %v, want 1", got)
+	}
+
+	// client id is not a secret, default auth requires a token
+	for _, unauthorized := range []*RemoteStore{
+		NewRemoteStore(server.URL, DefaultStoreKey),
+		NewRemoteStore(server.URL, DefaultStoreKey).SetClientId("farm"),
+		NewRemoteStore(server.URL, DefaultStoreKey).SetUiToken("invalid"),
+	} {
+		if _, err := unauthorized.Pool().Get("login"); err == nil {
+			t.Errorf("Get() without valid token, want error")
+		}
+	}
+}
+
+func TestClientIdAuth(t *testing.T) {
+	identity.Instance.AddClient("farm")
+	defer identity.Instance.RemoveClient("farm")
+
+	store := New()
+	store.Use(DefaultStoreKey)
+	server := httptest.NewServer(NewHandler(store).SetAuth(ClientIdAuth(identity.Instance)))
+	defer server.Close()
+
+	if token := NewRemoteStore(server.URL, DefaultStoreKey).SetClientId("farm").Pool().Push("t1", nil, "login"); token == nil {
+		t.Errorf("Push() with registered client = nil, want token")
+	}
+	if token := NewRemoteStore(server.URL, DefaultStoreKey).SetClientId("unknown").Pool().Push("t1", nil, "login"); token != nil {
+		t.Errorf("Push() with unknown client = %v, want nil", token)
+	}
+}
@@ -1,12 +1,14 @@
 package capstore
 
 import (
+	"context"
 	"fmt"
 	"hash/crc32"
 	"sync"
 	"time"
 
 	"github.com/go-per/simpkg/i18n"
+	"github.com/go-per/simpkg/random"
 )
 
 // DefaultKey default key
@@ -17,6 +19,9 @@ type tokenMap map[string]map[string]Token
 
 // Token struct
 type Token struct {
+	ID         string    `json:"id"`
+	Source     string    `json:"source,omitempty"`
+	Store      string    `json:"store,omitempty"`
 	Value      string    `json:"value"`
 	Data       any       `json:"data"`
 	CreatedAt  time.Time `json:"created_at"`
@@ -30,9 +35,19 @@ type IPool interface {
 	SetMinToken(min int, action ...string)
 	SubscribeOnAdd(handler func())
 	SubscribeOnRemove(handler func())
+	Subscribe(handler func(Event))
 	Push(token string, data any, action ...string) *Token
+	PushFrom(source, token string, data any, action ...string) *Token
 	Get(action ...string) (*Token, error)
 	Len() interfaceMap
+	Stats() StatsSnapshot
+	ResetStats()
+	ReportBad(id string) error
+	ReportGood(id string) error
+	Sources() map[string]SourceStats
+	SetQuarantinePolicy(threshold float64, minReports int)
+	OnQuarantine(handler func(SourceStats))
+	ReleaseSource(source string)
 }
 
 // Pool instance.
@@ -44,6 +59,16 @@ type Pool struct {
 	tokensChecksum   interfaceMap
 	onAddHandlers    []func()
 	onRemoveHandlers []func()
+	eventHandlers    []func(Event)
+	stats            map[string]*actionStats
+	statsSince       time.Time
+
+	issued               map[string]issuedToken
+	sources              map[string]*sourceStats
+	feedbackLifeTime     time.Duration
+	quarantineThreshold  float64
+	quarantineMinReports int
+	onQuarantineHandlers []func(SourceStats)
 }
 
 // NewPool create New pool instance.
@@ -54,8 +79,15 @@ func NewPool() IPool {
 		minTokens:        make(map[string]int),
 		onAddHandlers:    make([]func(), 0),
 		onRemoveHandlers: make([]func(), 0),
+		eventHandlers:    make([]func(Event), 0),
+
+		issued:               make(map[string]issuedToken),
+		sources:              make(map[string]*sourceStats),
+		feedbackLifeTime:     time.Minute * 10,
+		onQuarantineHandlers: make([]func(SourceStats), 0),
 	}
 	m.reset()
+	m.ResetStats()
 
 	return m
 }
@@ -80,40 +112,68 @@ func (pool *Pool) SetMinToken(min int, action ...string) {
 
 // SubscribeOnAdd subscribe on add event
 func (pool *Pool) SubscribeOnAdd(fn func()) {
+	pool.lk.Lock()
 	pool.onAddHandlers = append(pool.onAddHandlers, fn)
+	pool.lk.Unlock()
 }
 
 // SubscribeOnRemove subscribe on remove event
 func (pool *Pool) SubscribeOnRemove(fn func()) {
+	pool.lk.Lock()
 	pool.onRemoveHandlers = append(pool.onRemoveHandlers, fn)
+	pool.lk.Unlock()
+}
+
+// Subscribe subscribe on all pool events
+func (pool *Pool) Subscribe(fn func(Event)) {
+	pool.lk.Lock()
+	pool.eventHandlers = append(pool.eventHandlers, fn)
+	pool.lk.Unlock()
 }
 
 // Push append Token to list
 func (pool *Pool) Push(token string, data any, action ...string) *Token {
+	return pool.PushFrom("", token, data, action...)
+}
+
+// PushFrom append Token produced by source (solver) to list
+func (pool *Pool) PushFrom(source, token string, data any, action ...string) *Token {
 	if token == "" {
 		return nil
 	}
 	if len(action) == 0 || action[0] == "" {
 		action = []string{DefaultKey}
 	}
+	if source == "" {
+		source = DefaultKey
+	}
 
 	pool.lk.Lock()
 	defer pool.lk.Unlock()
 
 	// create token checksum
+	actionName := action[0]
 	checksum := pool.makeChecksum(token)
 	_, exists := pool.tokensChecksum[checksum]
 	if exists {
+		pool.emit(newEvent(EventRejectedDuplicate, actionName, checksum, nil))
+		return nil
+	}
+
+	// reject tokens of quarantined sources
+	if s, ok := pool.sources[source]; ok && s.quarantined {
+		pool.emit(newEvent(EventRejectedSource, actionName, checksum, nil))
 		return nil
 	}
 
-	actionName := action[0]
 	if _, ok := pool.tokens[actionName]; !ok {
 		pool.tokens[actionName] = make(map[string]Token)
 	}
 
 	// insert Text
 	t := Token{
+		ID:         random.String(16),
+		Source:     source,
 		Value:      token,
 		Data:       data,
 		CreatedAt:  time.Now(),
@@ -123,18 +183,11 @@ func (pool *Pool) Push(token string, data any, action ...string) *Token {
 	pool.tokensChecksum[checksum] = ""
 	pool.tokens[actionName][checksum] = t
 	if pool.tokenLifeTime > 0 {
-		go func(actionName, checksum string) {
-			time.AfterFunc(pool.tokenLifeTime, func() {
-				pool.remove(actionName, checksum)
-			})
-
-			if pool.onAddHandlers != nil {
-				for _, fn := range pool.onAddHandlers {
-					go fn()
-				}
-			}
-		}(actionName, checksum)
+		time.AfterFunc(pool.tokenLifeTime, func() {
+			pool.expire(actionName, checksum)
+		})
 	}
+	pool.emit(newEvent(EventAdded, actionName, checksum, &t))
 
 	return &t
 }
@@ -144,29 +197,81 @@ func (pool *Pool) Get(action ...string) (*Token, error) {
 	if len(action) == 0 || action[0] == "" {
 		action = []string{DefaultKey}
 	}
-	tokens, ok := pool.tokens[action[0]]
-	if !ok || len(tokens) == 0 {
-		return nil, i18n.TranslateAsError("no_captcha_exists")
-	}
+
+	pool.lk.Lock()
+	defer pool.lk.Unlock()
 
 	// get first item
-	for _, token := range tokens {
-		pool.remove(action[0], pool.makeChecksum(token.Value))
-		return &token, nil
+	for checksum := range pool.tokens[action[0]] {
+		token, _ := pool.remove(action[0], checksum, EventConsumed)
+		pool.issue(token)
+		return token, nil
 	}
 
 	return nil, i18n.TranslateAsError("no_captcha_exists")
 }
 
+// waitToken calls get until it returns a token or context is done
+func waitToken(ctx context.Context, interval time.Duration, get func() (*Token, error)) (*Token, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		token, err := get()
+		if err == nil {
+			return token, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-ticker.C:
+		}
+	}
+}
+
 // Len returns tokens length
 func (pool *Pool) Len() interfaceMap {
+	pool.lk.RLock()
+	defer pool.lk.RUnlock()
+
 	tokens := interfaceMap{}
-	for action, tokenMap := range pool.Tokens() {
+	for action, tokenMap := range pool.tokens {
 		tokens[action] = len(tokenMap)
 	}
 	return tokens
 }
 
+// Stats returns snapshot of pool statistics per action
+func (pool *Pool) Stats() StatsSnapshot {
+	pool.lk.RLock()
+	defer pool.lk.RUnlock()
+
+	total := &actionStats{}
+	available := 0
+	snapshot := StatsSnapshot{
+		Time:    time.Now(),
+		Since:   pool.statsSince,
+		Actions: make(map[string]Stats, len(pool.stats)),
+	}
+	for action, stats := range pool.stats {
+		snapshot.Actions[action] = stats.snapshot(action, len(pool.tokens[action]))
+		available += len(pool.tokens[action])
+		total.add(stats)
+	}
+	snapshot.Total = total.snapshot("", available)
+
+	return snapshot
+}
+
+// ResetStats reset pool statistics
+func (pool *Pool) ResetStats() {
+	pool.lk.Lock()
+	pool.stats = make(map[string]*actionStats)
+	pool.statsSince = time.Now()
+	pool.lk.Unlock()
+}
+
 // makeChecksum makes token checksum
 func (pool *Pool) makeChecksum(token string) string {
 	table := crc32.MakeTable(crc32.IEEE)
@@ -174,18 +279,57 @@ func (pool *Pool) makeChecksum(token string) string {
 	return fmt.Sprintf("_%x", checksum)
 }
 
-// remove delete an item by checksum
-func (pool *Pool) remove(action, checksum string) {
+// expire delete an item by checksum if its lifetime is over
+func (pool *Pool) expire(action, checksum string) {
 	pool.lk.Lock()
 	defer pool.lk.Unlock()
+
+	// token may be consumed and pushed again meanwhile
+	token, ok := pool.tokens[action][checksum]
+	if !ok || time.Now().Before(token.ExpiryTime) {
+		return
+	}
+
+	pool.remove(action, checksum, EventExpired)
+}
+
+// remove delete an item by checksum, lock must be held by caller
+func (pool *Pool) remove(action, checksum string, reason EventType) (*Token, bool) {
+	token, ok := pool.tokens[action][checksum]
+	if !ok {
+		return nil, false
+	}
+
 	delete(pool.tokens[action], checksum)
 	delete(pool.tokensChecksum, checksum)
+	pool.emit(newEvent(reason, action, checksum, &token))
 
-	// on remove callback
-	if pool.onRemoveHandlers != nil {
-		for _, fn := range pool.onRemoveHandlers {
-			go fn()
-		}
+	return &token, true
+}
+
+// emit records event in stats and notify subscribers, lock must be held by caller
+func (pool *Pool) emit(event Event) {
+	stats, ok := pool.stats[event.Action]
+	if !ok {
+		stats = &actionStats{}
+		pool.stats[event.Action] = stats
+	}
+	stats.record(event)
+
+	for _, fn := range pool.eventHandlers {
+		go fn(event)
+	}
+
+	// legacy callbacks
+	var handlers []func()
+	switch event.Type {
+	case EventAdded:
+		handlers = pool.onAddHandlers
+	case EventConsumed, EventExpired, EventDiscarded:
+		handlers = pool.onRemoveHandlers
+	}
+	for _, fn := range handlers {
+		go fn()
 	}
 }
 
@@ -0,0 +1,150 @@
+package capstore
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPool_Stats(t *testing.T) {
+	pool := NewPool()
+	pool.SetTokenLifeTime(time.Millisecond * 50)
+
+	events := make(chan Event, 10)
+	pool.Subscribe(func(e Event) {
+		events <- e
+	})
+
+	pool.Push("t1", nil, "login")
+	pool.Push("t1", nil, "login")
+	pool.Push("t2", nil, "login")
+	if _, err := pool.Get("login"); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	time.Sleep(time.Millisecond * 100)
+
+	stats := pool.Stats().Actions["login"]
+	want := Stats{Action: "login", Produced: 2, Consumed: 1, Expired: 1, Rejected: 1, WasteRate: 0.5}
+	stats.AvgConsumeAge = 0
+	if stats != want {
+		t.Errorf("Stats() = %+v, want %+v", stats, want)
+	}
+
+	counts := map[EventType]int{}
+	for i := 0; i < 5; i++ {
+		select {
+		case e := <-events:
+			counts[e.Type]++
+			if e.Action != "login" || e.Checksum == "" {
+				t.Errorf("event = %+v, want action and checksum", e)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("missing events, got %v", counts)
+		}
+	}
+	if counts[EventAdded] != 2 || counts[EventConsumed] != 1 || counts[EventExpired] != 1 || counts[EventRejectedDuplicate] != 1 {
+		t.Errorf("events = %v", counts)
+	}
+}
+
+func TestPool_Quarantine(t *testing.T) {
+	pool := NewPool()
+	pool.SetQuarantinePolicy(0.5, 2)
+
+	quarantined := make(chan SourceStats, 1)
+	pool.OnQuarantine(func(s SourceStats) {
+		quarantined <- s
+	})
+
+	pool.PushFrom("solver-a", "a1", nil)
+	pool.PushFrom("solver-a", "a2", nil)
+	pool.PushFrom("solver-a", "a3", nil)
+	for i := 0; i < 2; i++ {
+		token, err := pool.Get()
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		if err = pool.ReportBad(token.ID); err != nil {
+			t.Fatalf("ReportBad() error = %v", err)
+		}
+	}
+	if err := pool.ReportBad("unknown"); err == nil {
+		t.Errorf("ReportBad() with unknown id, want error")
+	}
+
+	select {
+	case s := <-quarantined:
+		if s.Source != "solver-a" || s.Bad != 2 || !s.Quarantined {
+			t.Errorf("OnQuarantine() = %+v", s)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("OnQuarantine() not called")
+	}
+	if got := pool.Len()[DefaultKey]; got != 0 {
+		t.Errorf("Len() = %v, want quarantined tokens discarded", got)
+	}
+	if token := pool.PushFrom("solver-a", "a4", nil); token != nil {
+		t.Errorf("PushFrom() = %v, want quarantined source rejected", token)
+	}
+
+	pool.ReleaseSource("solver-a")
+	if token := pool.PushFrom("solver-a", "a4", nil); token == nil {
+		t.Errorf("PushFrom() after release = nil, want token")
+	}
+}
+
+func TestPool_SubscribeConcurrent(t *testing.T) {
+	pool := NewPool()
+
+	var added int32
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			pool.Subscribe(func(Event) {})
+			pool.SubscribeOnAdd(func() { atomic.AddInt32(&added, 1) })
+			pool.SubscribeOnRemove(func() {})
+		}()
+		go func(i int) {
+			defer wg.Done()
+			pool.Push("t"+strconv.Itoa(i), nil)
+			_, _ = pool.Get()
+		}(i)
+	}
+	wg.Wait()
+
+	pool.Push("last", nil)
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(&added) < 20 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if got := atomic.LoadInt32(&added); got < 20 {
+		t.Errorf("OnAdd handlers called %d times, want at least 20 for last token", got)
+	}
+}
+
+func TestPool_FeedbackConcurrent(t *testing.T) {
+	pool := NewPool().(*Pool)
+	pool.SetQuarantinePolicy(0.5, 1)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			pool.OnQuarantine(func(SourceStats) {})
+			pool.SetFeedbackLifeTime(time.Minute)
+		}()
+		go func(i int) {
+			defer wg.Done()
+			pool.PushFrom("solver-"+strconv.Itoa(i), "t"+strconv.Itoa(i), nil)
+			if token, err := pool.Get(); err == nil {
+				_ = pool.ReportBad(token.ID)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,239 @@
+package capstore
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/go-per/simpkg/client"
+	"github.com/go-per/simpkg/httpapi"
+	"github.com/go-per/simpkg/std"
+	"github.com/imroc/req/v3"
+)
+
+// RemoteStore is a store which talks to a remote captcha store http api
+type RemoteStore struct {
+	pool *remotePool
+}
+
+// remotePool implements IPool over http
+type remotePool struct {
+	client           *req.Client
+	storePath        string
+	wait             time.Duration
+	onAddHandlers    []func()
+	onRemoveHandlers []func()
+	eventHandlers    []func(Event)
+}
+
+// NewRemoteStore returns store for the remote store name served at baseUrl
+func NewRemoteStore(baseUrl, storeName string) *RemoteStore {
+	return &RemoteStore{
+		pool: &remotePool{
+			client:           client.New().SetBaseURL(baseUrl).SetCommonContentType("application/json"),
+			storePath:        "/stores/" + url.PathEscape(storeName),
+			onAddHandlers:    make([]func(), 0),
+			onRemoveHandlers: make([]func(), 0),
+			eventHandlers:    make([]func(Event), 0),
+		},
+	}
+}
+
+// SetClientId sets identity client id sent on each request
+func (store *RemoteStore) SetClientId(id string) *RemoteStore {
+	store.pool.client.SetCommonHeader(HeaderClientId, id)
+	return store
+}
+
+// SetUiToken sets identity ui token sent on each request
+func (store *RemoteStore) SetUiToken(token string) *RemoteStore {
+	store.pool.client.SetCommonHeader(httpapi.HeaderUiToken, token)
+	return store
+}
+
+// SetWait sets how long remote store waits for a token on Get
+func (store *RemoteStore) SetWait(d time.Duration) *RemoteStore {
+	store.pool.wait = d
+	store.pool.client.SetTimeout(d + time.Second*10)
+	return store
+}
+
+// Client returns http client
+func (store *RemoteStore) Client() *req.Client {
+	return store.pool.client
+}
+
+// Pool returns pool instance
+func (store *RemoteStore) Pool() IPool {
+	return store.pool
+}
+
+// Tokens is not supported by remote pool and returns empty map
+func (pool *remotePool) Tokens() tokenMap {
+	return make(tokenMap)
+}
+
+// SetTokenLifeTime is not supported by remote pool
+func (pool *remotePool) SetTokenLifeTime(time.Duration) {}
+
+// SetMinToken is not supported by remote pool
+func (pool *remotePool) SetMinToken(int, ...string) {}
+
+// SubscribeOnAdd subscribe on tokens pushed by this pool
+func (pool *remotePool) SubscribeOnAdd(fn func()) {
+	pool.onAddHandlers = append(pool.onAddHandlers, fn)
+}
+
+// SubscribeOnRemove subscribe on tokens consumed by this pool
+func (pool *remotePool) SubscribeOnRemove(fn func()) {
+	pool.onRemoveHandlers = append(pool.onRemoveHandlers, fn)
+}
+
+// Subscribe subscribe on events of tokens pushed or consumed by this pool
+func (pool *remotePool) Subscribe(fn func(Event)) {
+	pool.eventHandlers = append(pool.eventHandlers, fn)
+}
+
+// Push sends token to remote store
+func (pool *remotePool) Push(token string, data any, action ...string) *Token {
+	return pool.PushFrom("", token, data, action...)
+}
+
+// PushFrom sends token produced by source to remote store
+func (pool *remotePool) PushFrom(source, token string, data any, action ...string) *Token {
+	if token == "" {
+		return nil
+	}
+
+	request := PushRequest{Source: source, Value: token, Data: data}
+	if len(action) > 0 {
+		request.Action = action[0]
+	}
+
+	var result Token
+	if err := pool.do(pool.client.R().SetBody(request).SetSuccessResult(&result), http.MethodPost, "/tokens"); err != nil {
+		std.Error("Could not push token to remote store: %v", err)
+		return nil
+	}
+
+	pool.emit(newEvent(EventAdded, request.Action, "", &result))
+	return &result
+}
+
+// Get fetches a token from remote store
+func (pool *remotePool) Get(action ...string) (*Token, error) {
+	r := pool.client.R()
+	if len(action) > 0 && action[0] != "" {
+		r.SetQueryParam("action", action[0])
+	}
+	if pool.wait > 0 {
+		r.SetQueryParam("wait", pool.wait.String())
+	}
+
+	var result Token
+	if err := pool.do(r.SetSuccessResult(&result), http.MethodGet, "/tokens"); err != nil {
+		return nil, err
+	}
+
+	event := newEvent(EventConsumed, "", "", &result)
+	if len(action) > 0 {
+		event.Action = action[0]
+	}
+	pool.emit(event)
+
+	return &result, nil
+}
+
+// Len returns remote tokens length
+func (pool *remotePool) Len() interfaceMap {
+	var result map[string]int
+	if err := pool.do(pool.client.R().SetSuccessResult(&result), http.MethodGet, "/len"); err != nil {
+		std.Error("Could not get remote store length: %v", err)
+	}
+
+	tokens := interfaceMap{}
+	for action, count := range result {
+		tokens[action] = count
+	}
+	return tokens
+}
+
+// Stats returns remote statistics snapshot
+func (pool *remotePool) Stats() (snapshot StatsSnapshot) {
+	if err := pool.do(pool.client.R().SetSuccessResult(&snapshot), http.MethodGet, "/stats"); err != nil {
+		std.Error("Could not get remote store stats: %v", err)
+	}
+	return
+}
+
+// ResetStats reset remote statistics
+func (pool *remotePool) ResetStats() {
+	if err := pool.do(pool.client.R(), http.MethodDelete, "/stats"); err != nil {
+		std.Error("Could not reset remote store stats: %v", err)
+	}
+}
+
+// ReportBad reports token as rejected to remote store
+func (pool *remotePool) ReportBad(id string) error {
+	return pool.do(pool.client.R(), http.MethodPost, "/tokens/"+url.PathEscape(id)+"/bad")
+}
+
+// ReportGood reports token as accepted to remote store
+func (pool *remotePool) ReportGood(id string) error {
+	return pool.do(pool.client.R(), http.MethodPost, "/tokens/"+url.PathEscape(id)+"/good")
+}
+
+// Sources returns remote feedback stats per source
+func (pool *remotePool) Sources() map[string]SourceStats {
+	sources := make(map[string]SourceStats)
+	if err := pool.do(pool.client.R().SetSuccessResult(&sources), http.MethodGet, "/sources"); err != nil {
+		std.Error("Could not get remote store sources: %v", err)
+	}
+	return sources
+}
+
+// SetQuarantinePolicy is not supported by remote pool
+func (pool *remotePool) SetQuarantinePolicy(float64, int) {}
+
+// OnQuarantine is not supported by remote pool
+func (pool *remotePool) OnQuarantine(func(SourceStats)) {}
+
+// ReleaseSource releases source from quarantine on remote store
+func (pool *remotePool) ReleaseSource(source string) {
+	if err := pool.do(pool.client.R(), http.MethodDelete, "/sources/"+url.PathEscape(source)); err != nil {
+		std.Error("Could not release remote store source: %v", err)
+	}
+}
+
+// do sends request to store path
+func (pool *remotePool) do(r *req.Request, method, p string) error {
+	var failure httpapi.ErrorResponse
+	resp, err := r.SetErrorResult(&failure).Send(method, pool.storePath+p)
+	if err != nil {
+		return err
+	}
+	if resp.IsErrorState() {
+		if failure.Error == "" {
+			failure.Error = resp.Status
+		}
+		return errors.New(failure.Error)
+	}
+
+	return nil
+}
+
+// emit notify local subscribers
+func (pool *remotePool) emit(event Event) {
+	for _, fn := range pool.eventHandlers {
+		go fn(event)
+	}
+
+	handlers := pool.onAddHandlers
+	if event.Type != EventAdded {
+		handlers = pool.onRemoveHandlers
+	}
+	for _, fn := range handlers {
+		go fn()
+	}
+}
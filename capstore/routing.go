@@ -0,0 +1,153 @@
+package capstore
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+)
+
+// RoutingMode type
+type RoutingMode string
+
+const (
+	RoutingOrdered  RoutingMode = "ordered"
+	RoutingWeighted RoutingMode = "weighted"
+)
+
+// ErrNoRoute is returned when routing policy has no store
+var ErrNoRoute = errors.New("no store in routing policy")
+
+// Route struct
+type Route struct {
+	Store  string `json:"store"`
+	Weight int    `json:"weight"`
+}
+
+// RoutingPolicy struct
+// ordered policy walks routes in order, weighted policy picks the first store
+// by weight and falls back to the others by weight
+type RoutingPolicy struct {
+	Mode   RoutingMode `json:"mode"`
+	Routes []Route     `json:"routes"`
+}
+
+// ITokenProvider is implemented by stores which produce tokens on demand
+type ITokenProvider interface {
+	Provide(ctx context.Context, action string) (*Token, error)
+}
+
+// SolverFunc solves a captcha for action and returns token value and data
+type SolverFunc func(ctx context.Context, action string) (string, any, error)
+
+// SolverStore is a store which solves tokens on demand when its pool is empty
+type SolverStore struct {
+	pool   IPool
+	source string
+	solver SolverFunc
+}
+
+// NewSolverStore returns new on demand solver store
+func NewSolverStore(source string, solver SolverFunc) *SolverStore {
+	return &SolverStore{
+		pool:   NewPool(),
+		source: source,
+		solver: solver,
+	}
+}
+
+// Pool returns pool instance
+func (store *SolverStore) Pool() IPool {
+	return store.pool
+}
+
+// Provide returns pooled token or solves a new one
+func (store *SolverStore) Provide(ctx context.Context, action string) (*Token, error) {
+	if token, err := store.pool.Get(action); err == nil {
+		return token, nil
+	}
+
+	value, data, err := store.solver(ctx, action)
+	if err != nil {
+		return nil, err
+	}
+	if store.pool.PushFrom(store.source, value, data, action) == nil {
+		return nil, errors.New("solved token rejected")
+	}
+
+	return store.pool.Get(action)
+}
+
+// SetRouting sets routing policy of action, without action it is used for all actions
+func (store *CaptchaStore) SetRouting(policy RoutingPolicy, action ...string) {
+	store.locker.Lock()
+	store.routes[routingKey(action...)] = policy
+	store.locker.Unlock()
+}
+
+// RemoveRouting removes routing policy of action
+func (store *CaptchaStore) RemoveRouting(action ...string) {
+	store.locker.Lock()
+	delete(store.routes, routingKey(action...))
+	store.locker.Unlock()
+}
+
+// Routing returns routing policy of action or the default policy
+func (store *CaptchaStore) Routing(action string) (RoutingPolicy, bool) {
+	store.locker.RLock()
+	defer store.locker.RUnlock()
+
+	policy, ok := store.routes[routingKey(action)]
+	if !ok {
+		policy, ok = store.routes[DefaultKey]
+	}
+	return policy, ok
+}
+
+// candidates returns store names in the order they should be tried
+func (policy RoutingPolicy) candidates() []string {
+	routes := make([]Route, len(policy.Routes))
+	copy(routes, policy.Routes)
+
+	names := make([]string, 0, len(routes))
+	if policy.Mode != RoutingWeighted {
+		for _, route := range routes {
+			names = append(names, route.Store)
+		}
+		return names
+	}
+
+	// weighted random order without replacement
+	for len(routes) > 0 {
+		total := 0
+		for _, route := range routes {
+			total += routeWeight(route)
+		}
+
+		n := rand.Intn(total)
+		for i, route := range routes {
+			if n -= routeWeight(route); n < 0 {
+				names = append(names, route.Store)
+				routes = append(routes[:i], routes[i+1:]...)
+				break
+			}
+		}
+	}
+
+	return names
+}
+
+// routeWeight returns route weight, at least 1
+func routeWeight(route Route) int {
+	if route.Weight < 1 {
+		return 1
+	}
+	return route.Weight
+}
+
+// routingKey returns routing key of action
+func routingKey(action ...string) string {
+	if len(action) == 0 || action[0] == "" {
+		return DefaultKey
+	}
+	return action[0]
+}
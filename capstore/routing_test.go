@@ -0,0 +1,66 @@
+package capstore
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSelection_Routing(t *testing.T) {
+	store := New()
+	store.AddStore("local", newDefaultStore())
+	store.AddStore("farm", newDefaultStore())
+	store.AddStore("solver", NewSolverStore("solver", func(ctx context.Context, action string) (string, any, error) {
+		return "solved-" + action, nil, nil
+	}))
+	store.SetRouting(RoutingPolicy{
+		Mode:   RoutingOrdered,
+		Routes: []Route{{Store: "local"}, {Store: "farm"}, {Store: "solver"}},
+	}, "login")
+
+	store.Select("local").Action("login").Push("l1", nil)
+	store.Select("farm").Action("login").Push("f1", nil)
+
+	tests := []struct {
+		name      string
+		wantValue string
+		wantStore string
+	}{
+		{"local first", "l1", "local"},
+		{"fallback to farm", "f1", "farm"},
+		{"fallback to solver", "solved-login", "solver"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := store.Action("login").GetToken()
+			if err != nil {
+				t.Fatalf("GetToken() error = %v", err)
+			}
+			if token.Value != tt.wantValue || token.Store != tt.wantStore {
+				t.Errorf("GetToken() = %v from %v, want %v from %v", token.Value, token.Store, tt.wantValue, tt.wantStore)
+			}
+		})
+	}
+
+	if _, err := store.Action("pay").GetToken(); err == nil {
+		t.Errorf("GetToken() for action without routing and active store, want error")
+	}
+}
+
+func TestRoutingPolicy_Weighted(t *testing.T) {
+	policy := RoutingPolicy{
+		Mode:   RoutingWeighted,
+		Routes: []Route{{Store: "a", Weight: 9}, {Store: "b", Weight: 1}},
+	}
+
+	first := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		names := policy.candidates()
+		if len(names) != 2 || names[0] == names[1] {
+			t.Fatalf("candidates() = %v, want both stores once", names)
+		}
+		first[names[0]]++
+	}
+	if first["a"] < first["b"] {
+		t.Errorf("candidates() picked first %v, want a picked more than b", first)
+	}
+}
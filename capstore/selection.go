@@ -0,0 +1,138 @@
+package capstore
+
+import (
+	"context"
+	"errors"
+)
+
+// Selection is an immutable store and action scope, safe to share between goroutines
+type Selection struct {
+	store     *CaptchaStore
+	storeName string
+	action    string
+}
+
+// Select returns copy of selection on the named store
+func (s *Selection) Select(storeName string) *Selection {
+	return &Selection{store: s.store, storeName: storeName, action: s.action}
+}
+
+// Action returns copy of selection on action
+func (s *Selection) Action(action string) *Selection {
+	return &Selection{store: s.store, storeName: s.storeName, action: action}
+}
+
+// StoreName returns selected store name, active store name if not selected
+func (s *Selection) StoreName() string {
+	if s.storeName == "" {
+		return s.store.GetActiveName()
+	}
+	return s.storeName
+}
+
+// ActionName returns selected action name
+func (s *Selection) ActionName() string {
+	return s.action
+}
+
+// Store returns selected store
+func (s *Selection) Store() IStore {
+	st, _ := s.store.Get(s.StoreName())
+	return st
+}
+
+// Pool returns selected store pool
+func (s *Selection) Pool() IPool {
+	st := s.Store()
+	if st == nil {
+		return nil
+	}
+	return st.Pool()
+}
+
+// GetToken returns token without waiting, token.Store is the store which served it
+func (s *Selection) GetToken() (*Token, error) {
+	return s.get(context.Background())
+}
+
+// Get returns token and waits for a new one until context is done
+func (s *Selection) Get(ctx context.Context) (*Token, error) {
+	s.store.locker.RLock()
+	interval := s.store.pollInterval
+	s.store.locker.RUnlock()
+
+	return waitToken(ctx, interval, func() (*Token, error) {
+		return s.get(ctx)
+	})
+}
+
+// get walks selected stores and returns first served token
+func (s *Selection) get(ctx context.Context) (token *Token, err error) {
+	names := s.storeNames()
+	if len(names) == 0 {
+		return nil, ErrNoRoute
+	}
+
+	err = errors.New("no active store")
+	for _, name := range names {
+		st, ok := s.store.Get(name)
+		if !ok {
+			continue
+		}
+
+		if provider, ok := st.(ITokenProvider); ok {
+			token, err = provider.Provide(ctx, s.action)
+		} else {
+			token, err = st.Pool().Get(s.action)
+		}
+		if err == nil {
+			token.Store = name
+			return
+		}
+	}
+
+	return
+}
+
+// storeNames returns store names to try, selected store, routing policy or active store
+func (s *Selection) storeNames() []string {
+	if s.storeName != "" {
+		return []string{s.storeName}
+	}
+
+	if policy, ok := s.store.Routing(s.action); ok {
+		return policy.candidates()
+	}
+
+	return []string{s.store.GetActiveName()}
+}
+
+// Push append token to selected store
+func (s *Selection) Push(token string, data any) *Token {
+	return s.PushFrom("", token, data)
+}
+
+// PushFrom append token produced by source to selected store
+func (s *Selection) PushFrom(source, token string, data any) *Token {
+	pool := s.Pool()
+	if pool == nil {
+		return nil
+	}
+
+	return pool.PushFrom(source, token, data, s.action)
+}
+
+// Len returns tokens length of selected action
+func (s *Selection) Len() int {
+	pool := s.Pool()
+	if pool == nil {
+		return 0
+	}
+
+	action := s.action
+	if action == "" {
+		action = DefaultKey
+	}
+	count, _ := pool.Len()[action].(int)
+	return count
+}
@@ -0,0 +1,82 @@
+package capstore
+
+import "time"
+
+// Stats struct is a snapshot of an action counters
+type Stats struct {
+	Action        string        `json:"action"`
+	Available     int           `json:"available"`
+	Produced      int           `json:"produced"`
+	Consumed      int           `json:"consumed"`
+	Expired       int           `json:"expired"`
+	Discarded     int           `json:"discarded"`
+	Rejected      int           `json:"rejected"`
+	AvgConsumeAge time.Duration `json:"avg_consume_age"`
+	WasteRate     float64       `json:"waste_rate"`
+}
+
+// StatsSnapshot struct
+type StatsSnapshot struct {
+	Time    time.Time        `json:"time"`
+	Since   time.Time        `json:"since"`
+	Total   Stats            `json:"total"`
+	Actions map[string]Stats `json:"actions"`
+}
+
+// actionStats keeps action counters
+type actionStats struct {
+	produced      int
+	consumed      int
+	expired       int
+	discarded     int
+	rejected      int
+	consumeAgeSum time.Duration
+}
+
+// record updates counters by event
+func (s *actionStats) record(event Event) {
+	switch event.Type {
+	case EventAdded:
+		s.produced++
+	case EventConsumed:
+		s.consumed++
+		s.consumeAgeSum += event.Age
+	case EventExpired:
+		s.expired++
+	case EventDiscarded:
+		s.discarded++
+	case EventRejectedDuplicate, EventRejectedSource:
+		s.rejected++
+	}
+}
+
+// add merges counters into s
+func (s *actionStats) add(other *actionStats) {
+	s.produced += other.produced
+	s.consumed += other.consumed
+	s.expired += other.expired
+	s.discarded += other.discarded
+	s.rejected += other.rejected
+	s.consumeAgeSum += other.consumeAgeSum
+}
+
+// snapshot returns stats of counters
+func (s *actionStats) snapshot(action string, available int) Stats {
+	stats := Stats{
+		Action:    action,
+		Available: available,
+		Produced:  s.produced,
+		Consumed:  s.consumed,
+		Expired:   s.expired,
+		Discarded: s.discarded,
+		Rejected:  s.rejected,
+	}
+	if s.consumed > 0 {
+		stats.AvgConsumeAge = s.consumeAgeSum / time.Duration(s.consumed)
+	}
+	if s.produced > 0 {
+		stats.WasteRate = float64(s.expired+s.discarded) / float64(s.produced)
+	}
+
+	return stats
+}
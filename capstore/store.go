@@ -2,13 +2,13 @@ package capstore
 
 import (
 	"errors"
+	"sort"
 	"sync"
+	"time"
 )
 
 // Instance store instance
 var Instance *CaptchaStore
-var storeNameLocker = sync.RWMutex{}
-var actionNameLocker = sync.RWMutex{}
 
 // IStore store interface
 type IStore interface {
@@ -17,9 +17,11 @@ type IStore interface {
 
 // CaptchaStore captcha store
 type CaptchaStore struct {
-	stores      map[string]IStore
-	activeStore string
-	actionName  []string
+	stores       map[string]IStore
+	activeStore  string
+	routes       map[string]RoutingPolicy
+	pollInterval time.Duration
+	locker       sync.RWMutex
 }
 
 // initialize
@@ -30,7 +32,10 @@ func init() {
 // New creates new instance
 func New() *CaptchaStore {
 	instance := &CaptchaStore{
-		stores: make(map[string]IStore, 0),
+		stores:       make(map[string]IStore, 0),
+		routes:       make(map[string]RoutingPolicy),
+		pollInterval: time.Millisecond * 200,
+		locker:       sync.RWMutex{},
 	}
 
 	// add default store
@@ -41,49 +46,83 @@ func New() *CaptchaStore {
 
 // AddStore add new store
 func (store *CaptchaStore) AddStore(name string, s IStore) {
+	store.locker.Lock()
 	store.stores[name] = s
+	store.locker.Unlock()
+}
+
+// Get returns store by name
+func (store *CaptchaStore) Get(name string) (IStore, bool) {
+	store.locker.RLock()
+	s, ok := store.stores[name]
+	store.locker.RUnlock()
+
+	return s, ok
+}
+
+// Names returns sorted store names
+func (store *CaptchaStore) Names() []string {
+	store.locker.RLock()
+	names := make([]string, 0, len(store.stores))
+	for name := range store.stores {
+		names = append(names, name)
+	}
+	store.locker.RUnlock()
+	sort.Strings(names)
+
+	return names
 }
 
 // Use set active store name
 func (store *CaptchaStore) Use(storeName string) {
-	storeNameLocker.Lock()
+	store.locker.Lock()
 	store.activeStore = storeName
-	storeNameLocker.Unlock()
+	store.locker.Unlock()
 }
 
-// WithAction set action name
-func (store *CaptchaStore) WithAction(action ...string) *CaptchaStore {
-	actionNameLocker.Lock()
-	store.actionName = action
-	actionNameLocker.Unlock()
-	return store
+// SetPollInterval sets how often Selection.Get checks for a new token
+func (store *CaptchaStore) SetPollInterval(d time.Duration) {
+	store.locker.Lock()
+	store.pollInterval = d
+	store.locker.Unlock()
 }
 
-// GetToken returns token
-func (store *CaptchaStore) GetToken() (token *Token, err error) {
-	current := store.Current()
-	if current == nil {
-		err = errors.New("no active store")
-		return
-	}
+// Select returns selection of the named store
+func (store *CaptchaStore) Select(storeName string) *Selection {
+	return &Selection{store: store, storeName: storeName}
+}
 
-	token, err = current.Pool().Get(store.actionName...)
-	store.resetAction()
-	return
+// Action returns selection of action on active store
+func (store *CaptchaStore) Action(action string) *Selection {
+	return &Selection{store: store, action: action}
+}
+
+// WithAction returns selection of action on active store
+func (store *CaptchaStore) WithAction(action ...string) *Selection {
+	if len(action) == 0 {
+		return store.Action("")
+	}
+	return store.Action(action[0])
 }
 
-// resetAction reset action name
-func (store *CaptchaStore) resetAction() {
-	store.actionName = []string{}
+// GetToken returns token of default action from active store
+func (store *CaptchaStore) GetToken() (token *Token, err error) {
+	return store.Action("").GetToken()
 }
 
 // GetActiveName returns active store name
 func (store *CaptchaStore) GetActiveName() string {
+	store.locker.RLock()
+	defer store.locker.RUnlock()
+
 	return store.activeStore
 }
 
 // Current returns active store
 func (store *CaptchaStore) Current() IStore {
+	store.locker.RLock()
+	defer store.locker.RUnlock()
+
 	return store.stores[store.activeStore]
 }
 
@@ -96,3 +135,42 @@ func (store *CaptchaStore) Pool() IPool {
 
 	return current.Pool()
 }
+
+// Stats returns statistics snapshot of all stores
+func (store *CaptchaStore) Stats() map[string]StatsSnapshot {
+	stats := make(map[string]StatsSnapshot)
+	for _, name := range store.Names() {
+		s, _ := store.Get(name)
+		stats[name] = s.Pool().Stats()
+	}
+
+	return stats
+}
+
+// ReportBad reports token as rejected to the store which issued it
+func (store *CaptchaStore) ReportBad(id string) error {
+	return store.report(id, false)
+}
+
+// ReportGood reports token as accepted to the store which issued it
+func (store *CaptchaStore) ReportGood(id string) error {
+	return store.report(id, true)
+}
+
+// report sends token feedback to stores until one accepts it
+func (store *CaptchaStore) report(id string, good bool) (err error) {
+	err = errors.New("no store exists")
+	for _, name := range store.Names() {
+		s, _ := store.Get(name)
+		if good {
+			err = s.Pool().ReportGood(id)
+		} else {
+			err = s.Pool().ReportBad(id)
+		}
+		if err == nil {
+			return
+		}
+	}
+
+	return
+}
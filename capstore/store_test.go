@@ -0,0 +1,69 @@
+package capstore
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCaptchaStore_WithActionConcurrent(t *testing.T) {
+	store := New()
+	store.Use(DefaultStoreKey)
+
+	const count = 200
+	actions := []string{"login", "pay"}
+	for _, action := range actions {
+		for i := 0; i < count; i++ {
+			store.Action(action).Push(fmt.Sprintf("%s-%d", action, i), action)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, action := range actions {
+		for i := 0; i < count; i++ {
+			wg.Add(1)
+			go func(action string) {
+				defer wg.Done()
+				token, err := store.WithAction(action).GetToken()
+				if err != nil {
+					t.Errorf("GetToken(%s) error = %v", action, err)
+					return
+				}
+				if token.Data != action {
+					t.Errorf("GetToken(%s) = token of %v", action, token.Data)
+				}
+			}(action)
+		}
+	}
+	wg.Wait()
+
+	for _, action := range actions {
+		if got := store.Action(action).Len(); got != 0 {
+			t.Errorf("Len(%s) = %v, want 0", action, got)
+		}
+	}
+}
+
+func TestSelection_Get(t *testing.T) {
+	store := New()
+	store.SetPollInterval(time.Millisecond * 10)
+	login := store.Select(DefaultStoreKey).Action("login")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	if _, err := login.Get(ctx); err == nil {
+		t.Errorf("Get() on empty pool, want error")
+	}
+
+	go func() {
+		time.Sleep(time.Millisecond * 30)
+		login.Push("t1", nil)
+	}()
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if token, err := login.Get(ctx); err != nil || token.Value != "t1" {
+		t.Errorf("Get() = %v, %v, want t1", token, err)
+	}
+}
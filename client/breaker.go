@@ -0,0 +1,355 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-per/simpkg/events"
+	"github.com/imroc/req/v3"
+)
+
+// BreakerState type
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"
+	BreakerOpen     BreakerState = "open"
+	BreakerHalfOpen BreakerState = "half_open"
+)
+
+// EventBreakerStateChange is dispatched with BreakerEvent when a host circuit changes state
+const EventBreakerStateChange = "client.breaker.state_change"
+
+// shared breaker of all clients
+var (
+	sharedBreaker       *CircuitBreaker
+	sharedBreakerLocker sync.RWMutex
+)
+
+// BreakerConfig struct
+type BreakerConfig struct {
+	Window         time.Duration                             // failure rate window
+	MinRequests    int                                       // min requests in window before opening
+	FailureRate    float64                                   // failure rate which opens the circuit
+	OpenTimeout    time.Duration                             // how long circuit stays open before probing
+	HalfOpenProbes int                                       // concurrent probes in half open state
+	IsFailure      func(resp *http.Response, err error) bool // default is error or 5xx status, context errors are not failures
+}
+
+// BreakerEvent struct
+type BreakerEvent struct {
+	Host        string       `json:"host"`
+	From        BreakerState `json:"from"`
+	To          BreakerState `json:"to"`
+	FailureRate float64      `json:"failure_rate"`
+	Owners      []string     `json:"owners,omitempty"` // owners of clients which used host, e.g. worker ids
+	Time        time.Time    `json:"time"`
+}
+
+// ErrCircuitOpen is returned without sending request while host circuit is open
+type ErrCircuitOpen struct {
+	Host  string
+	Until time.Time
+}
+
+// Error implements error
+func (e *ErrCircuitOpen) Error() string {
+	return fmt.Sprintf("circuit is open for %v until %v", e.Host, e.Until.Format(time.RFC3339))
+}
+
+// CircuitBreaker tracks failure rate per host and fails fast while host is down
+type CircuitBreaker struct {
+	config   BreakerConfig
+	hosts    map[string]*hostBreaker
+	eventbus events.IEventbus
+	locker   sync.RWMutex
+}
+
+// hostBreaker is the circuit of one host
+type hostBreaker struct {
+	state       BreakerState
+	windowStart time.Time
+	requests    int
+	failures    int
+	openedAt    time.Time
+	probes      int
+	owners      map[string]bool
+}
+
+// NewCircuitBreaker returns new circuit breaker, events are dispatched on events.Instance
+func NewCircuitBreaker(config ...BreakerConfig) *CircuitBreaker {
+	c := BreakerConfig{}
+	if len(config) > 0 {
+		c = config[0]
+	}
+	if c.Window <= 0 {
+		c.Window = time.Minute
+	}
+	if c.MinRequests < 1 {
+		c.MinRequests = 10
+	}
+	if c.FailureRate <= 0 {
+		c.FailureRate = 0.5
+	}
+	if c.OpenTimeout <= 0 {
+		c.OpenTimeout = time.Second * 30
+	}
+	if c.HalfOpenProbes < 1 {
+		c.HalfOpenProbes = 1
+	}
+	if c.IsFailure == nil {
+		c.IsFailure = func(resp *http.Response, err error) bool {
+			if err != nil {
+				return !errors.Is(err, context.Canceled)
+			}
+			return resp.StatusCode >= http.StatusInternalServerError
+		}
+	}
+
+	return &CircuitBreaker{
+		config:   c,
+		hosts:    make(map[string]*hostBreaker),
+		eventbus: events.Instance,
+		locker:   sync.RWMutex{},
+	}
+}
+
+// SetSharedCircuitBreaker sets breaker used by every client which has no own breaker, nil disables it
+func SetSharedCircuitBreaker(b *CircuitBreaker) {
+	sharedBreakerLocker.Lock()
+	sharedBreaker = b
+	sharedBreakerLocker.Unlock()
+}
+
+// SharedCircuitBreaker returns shared breaker
+func SharedCircuitBreaker() *CircuitBreaker {
+	sharedBreakerLocker.RLock()
+	defer sharedBreakerLocker.RUnlock()
+
+	return sharedBreaker
+}
+
+// WithCircuitBreaker sets client circuit breaker, owner (e.g. worker id) is reported in events
+func WithCircuitBreaker(b *CircuitBreaker, owner ...string) Option {
+	return func(o *Options) {
+		o.CircuitBreaker = b
+		if len(owner) > 0 {
+			o.BreakerOwner = owner[0]
+		}
+	}
+}
+
+// SetEventbus sets eventbus of state changes
+func (b *CircuitBreaker) SetEventbus(bus events.IEventbus) {
+	b.locker.Lock()
+	b.eventbus = bus
+	b.locker.Unlock()
+}
+
+// State returns circuit state of host
+func (b *CircuitBreaker) State(host string) BreakerState {
+	b.locker.RLock()
+	defer b.locker.RUnlock()
+
+	if hb, ok := b.hosts[strings.ToLower(host)]; ok {
+		return hb.state
+	}
+	return BreakerClosed
+}
+
+// States returns circuit states of known hosts
+func (b *CircuitBreaker) States() map[string]BreakerState {
+	b.locker.RLock()
+	defer b.locker.RUnlock()
+
+	states := make(map[string]BreakerState, len(b.hosts))
+	for host, hb := range b.hosts {
+		states[host] = hb.state
+	}
+	return states
+}
+
+// Reset closes circuit of host
+func (b *CircuitBreaker) Reset(host string) {
+	host = strings.ToLower(host)
+
+	b.locker.Lock()
+	hb, ok := b.hosts[host]
+	var event *BreakerEvent
+	if ok {
+		event = b.transition(host, hb, BreakerClosed, time.Now())
+	}
+	b.locker.Unlock()
+
+	b.dispatch(event)
+}
+
+// Allow returns ErrCircuitOpen when request to host must not be sent,
+// allowed requests must be finished by Done
+func (b *CircuitBreaker) Allow(host string, owner ...string) error {
+	host = strings.ToLower(host)
+	now := time.Now()
+
+	b.locker.Lock()
+	hb := b.host(host)
+	if len(owner) > 0 && owner[0] != "" {
+		hb.owners[owner[0]] = true
+	}
+
+	var event *BreakerEvent
+	var err error
+	switch hb.state {
+	case BreakerOpen:
+		if until := hb.openedAt.Add(b.config.OpenTimeout); now.Before(until) {
+			err = &ErrCircuitOpen{Host: host, Until: until}
+			break
+		}
+		event = b.transition(host, hb, BreakerHalfOpen, now)
+		hb.probes++
+	case BreakerHalfOpen:
+		if hb.probes >= b.config.HalfOpenProbes {
+			err = &ErrCircuitOpen{Host: host, Until: now.Add(b.config.OpenTimeout)}
+			break
+		}
+		hb.probes++
+	}
+	b.locker.Unlock()
+
+	b.dispatch(event)
+	return err
+}
+
+// Done records result of allowed request
+func (b *CircuitBreaker) Done(host string, failed bool) {
+	host = strings.ToLower(host)
+	now := time.Now()
+
+	b.locker.Lock()
+	hb := b.host(host)
+
+	var event *BreakerEvent
+	switch hb.state {
+	case BreakerHalfOpen:
+		if hb.probes > 0 {
+			hb.probes--
+		}
+		if failed {
+			event = b.transition(host, hb, BreakerOpen, now)
+		} else {
+			event = b.transition(host, hb, BreakerClosed, now)
+		}
+	case BreakerClosed:
+		if now.Sub(hb.windowStart) > b.config.Window {
+			hb.windowStart, hb.requests, hb.failures = now, 0, 0
+		}
+		hb.requests++
+		if failed {
+			hb.failures++
+		}
+		if hb.requests >= b.config.MinRequests && hb.failureRate() >= b.config.FailureRate {
+			event = b.transition(host, hb, BreakerOpen, now)
+		}
+	}
+	b.locker.Unlock()
+
+	b.dispatch(event)
+}
+
+// Attach fails fast requests of client while host circuit is open
+func (b *CircuitBreaker) Attach(c *req.Client, owner ...string) {
+	c.GetTransport().WrapRoundTripFunc(func(rt http.RoundTripper) req.HttpRoundTripFunc {
+		return func(r *http.Request) (*http.Response, error) {
+			host := r.URL.Hostname()
+			if err := b.Allow(host, owner...); err != nil {
+				return nil, err
+			}
+
+			resp, err := rt.RoundTrip(r)
+			if err != nil && errors.Is(r.Context().Err(), context.Canceled) {
+				// canceled by caller, says nothing about host, timeouts still count
+				b.release(host)
+				return resp, err
+			}
+			b.Done(host, b.config.IsFailure(resp, err))
+			return resp, err
+		}
+	})
+}
+
+// release frees the probe of a request which has no result
+func (b *CircuitBreaker) release(host string) {
+	b.locker.Lock()
+	defer b.locker.Unlock()
+
+	if hb := b.host(strings.ToLower(host)); hb.state == BreakerHalfOpen && hb.probes > 0 {
+		hb.probes--
+	}
+}
+
+// host returns breaker of host, caller must hold the lock
+func (b *CircuitBreaker) host(host string) *hostBreaker {
+	hb, ok := b.hosts[host]
+	if !ok {
+		hb = &hostBreaker{state: BreakerClosed, windowStart: time.Now(), owners: make(map[string]bool)}
+		b.hosts[host] = hb
+	}
+	return hb
+}
+
+// transition changes host state and returns event, caller must hold the lock
+func (b *CircuitBreaker) transition(host string, hb *hostBreaker, to BreakerState, now time.Time) *BreakerEvent {
+	if hb.state == to {
+		return nil
+	}
+
+	event := &BreakerEvent{
+		Host:        host,
+		From:        hb.state,
+		To:          to,
+		FailureRate: hb.failureRate(),
+		Time:        now,
+	}
+	for owner := range hb.owners {
+		event.Owners = append(event.Owners, owner)
+	}
+	sort.Strings(event.Owners)
+
+	hb.state = to
+	switch to {
+	case BreakerOpen:
+		hb.openedAt = now
+		hb.probes = 0
+	case BreakerClosed:
+		hb.windowStart, hb.requests, hb.failures, hb.probes = now, 0, 0, 0
+	}
+
+	return event
+}
+
+// dispatch dispatches state change event
+func (b *CircuitBreaker) dispatch(event *BreakerEvent) {
+	if event == nil {
+		return
+	}
+
+	b.locker.RLock()
+	bus := b.eventbus
+	b.locker.RUnlock()
+	if bus != nil {
+		bus.DispatchAsync(EventBreakerStateChange, *event)
+	}
+}
+
+// failureRate returns failure rate of current window
+func (hb *hostBreaker) failureRate() float64 {
+	if hb.requests == 0 {
+		return 0
+	}
+	return float64(hb.failures) / float64(hb.requests)
+}
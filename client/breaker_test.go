@@ -0,0 +1,123 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-per/simpkg/events"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	var down int32 = 1
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&down) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	bus := events.New()
+	changes := make(chan BreakerEvent, 10)
+	bus.Subscribe(EventBreakerStateChange, func(v ...any) { changes <- v[0].(BreakerEvent) })
+
+	breaker := NewCircuitBreaker(BreakerConfig{MinRequests: 3, FailureRate: 0.5, OpenTimeout: time.Millisecond * 50})
+	breaker.SetEventbus(bus)
+	c := NewWithOptions(WithCircuitBreaker(breaker, "worker-1"))
+
+	for i := 0; i < 3; i++ {
+		_, _ = c.R().Get(server.URL)
+	}
+	_, err := c.R().Get(server.URL)
+	var openErr *ErrCircuitOpen
+	if !errors.As(err, &openErr) {
+		t.Fatalf("Get() error = %v, want ErrCircuitOpen", err)
+	}
+	if event := <-changes; event.To != BreakerOpen || len(event.Owners) != 1 || event.Owners[0] != "worker-1" {
+		t.Errorf("event = %+v, want open by worker-1", event)
+	}
+
+	// half open probe closes circuit
+	atomic.StoreInt32(&down, 0)
+	time.Sleep(time.Millisecond * 60)
+	if _, err = c.R().Get(server.URL); err != nil {
+		t.Fatalf("Get() probe error = %v", err)
+	}
+	if state := breaker.State("127.0.0.1"); state != BreakerClosed {
+		t.Errorf("State() = %v, want %v", state, BreakerClosed)
+	}
+}
+
+func TestCircuitBreaker_Canceled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	breaker := NewCircuitBreaker(BreakerConfig{MinRequests: 3, FailureRate: 0.5})
+	breaker.SetEventbus(events.New())
+	c := NewWithOptions(WithCircuitBreaker(breaker, "worker-1"))
+
+	for i := 0; i < 5; i++ {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(time.Millisecond*10, cancel)
+		if _, err := c.R().SetContext(ctx).Get(server.URL); err == nil {
+			t.Errorf("Get() error = nil, want context error")
+		}
+		cancel()
+	}
+	if state := breaker.State("127.0.0.1"); state != BreakerClosed {
+		t.Errorf("State() after canceled requests = %v, want %v", state, BreakerClosed)
+	}
+
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{context.Canceled, false},
+		{fmt.Errorf("read: %w", context.DeadlineExceeded), true},
+		{errors.New("connection reset"), true},
+	}
+	for _, tt := range tests {
+		if got := breaker.config.IsFailure(nil, tt.err); got != tt.want {
+			t.Errorf("IsFailure(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestCircuitBreaker_Timeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	breaker := NewCircuitBreaker(BreakerConfig{MinRequests: 3, FailureRate: 0.5})
+	breaker.SetEventbus(events.New())
+	c := NewWithOptions(WithTimeout(time.Millisecond*50), WithCircuitBreaker(breaker, "worker-1"))
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.R().Get(server.URL); err == nil {
+			t.Errorf("Get() error = nil, want timeout error")
+		}
+	}
+	if state := breaker.State("127.0.0.1"); state != BreakerOpen {
+		t.Errorf("State() after timed out requests = %v, want %v", state, BreakerOpen)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	breaker.Reset("127.0.0.1")
+	for i := 0; i < 3; i++ {
+		if _, err := c.R().SetContext(ctx).Get(server.URL); err == nil {
+			t.Errorf("Get() error = nil, want deadline error")
+		}
+	}
+	if state := breaker.State("127.0.0.1"); state != BreakerOpen {
+		t.Errorf("State() after deadline exceeded requests = %v, want %v", state, BreakerOpen)
+	}
+}
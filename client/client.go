@@ -1,17 +1,16 @@
 package client
 
 import (
-	"github.com/go-per/simpkg/useragent"
 	"github.com/imroc/req/v3"
 )
 
-// New creates new http client
-func New() *req.Client {
-	client := req.NewClient().
-		SetUserAgent(useragent.Random()).
-		DisableAutoDecode().
-		EnableInsecureSkipVerify().
-		EnableKeepAlives()
+// New creates new http client with browser preset,
+// key (e.g. worker id) selects the same browser profile on every call
+func New(key ...string) *req.Client {
+	opts, _ := PresetOptions(PresetBrowser)
+	if len(key) > 0 && key[0] != "" {
+		opts = append(opts, WithProfile(ProfileFor(key[0])))
+	}
 
-	return client
+	return NewWithOptions(opts...)
 }
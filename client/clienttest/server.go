@@ -0,0 +1,326 @@
+package clienttest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/imroc/req/v3"
+)
+
+// Server is a fake upstream server based on httptest
+type Server struct {
+	*httptest.Server
+	routes   []*Route
+	requests []CapturedRequest
+	locker   sync.RWMutex
+}
+
+// CapturedRequest is a received request
+type CapturedRequest struct {
+	Method  string
+	Host    string
+	Path    string
+	Query   url.Values
+	Header  http.Header
+	Cookies []*http.Cookie
+	Body    []byte
+	Time    time.Time
+}
+
+// NewServer starts new fake http server
+func NewServer() *Server {
+	s := &Server{}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// NewTLSServer starts new fake https server
+func NewTLSServer() *Server {
+	s := &Server{}
+	s.Server = httptest.NewTLSServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// Handle adds route of method and path, empty method or "*" matches all methods,
+// path ending with * matches the prefix
+func (s *Server) Handle(method, path string) *Route {
+	route := &Route{method: strings.ToUpper(method), path: path}
+
+	s.locker.Lock()
+	s.routes = append(s.routes, route)
+	s.locker.Unlock()
+
+	return route
+}
+
+// RedirectChain adds redirects from each path to the next one, last path should be handled separately
+func (s *Server) RedirectChain(status int, paths ...string) {
+	for i := 0; i < len(paths)-1; i++ {
+		s.Handle("*", paths[i]).Redirect(status, paths[i+1])
+	}
+}
+
+// Attach sends client requests to server without changing request urls,
+// host header, cookies and form endpoints keep the original host
+func (s *Server) Attach(c *req.Client) {
+	target, _ := url.Parse(s.URL)
+	c.EnableInsecureSkipVerify()
+	c.GetTransport().WrapRoundTripFunc(func(rt http.RoundTripper) req.HttpRoundTripFunc {
+		return func(r *http.Request) (*http.Response, error) {
+			r = r.Clone(r.Context())
+			if r.Host == "" {
+				r.Host = r.URL.Host
+			}
+			r.URL.Scheme = target.Scheme
+			r.URL.Host = target.Host
+			return rt.RoundTrip(r)
+		}
+	})
+}
+
+// Requests returns captured requests
+func (s *Server) Requests() []CapturedRequest {
+	s.locker.RLock()
+	defer s.locker.RUnlock()
+
+	return append([]CapturedRequest{}, s.requests...)
+}
+
+// RequestsTo returns captured requests of path
+func (s *Server) RequestsTo(path string) []CapturedRequest {
+	requests := make([]CapturedRequest, 0)
+	for _, r := range s.Requests() {
+		if r.Path == path {
+			requests = append(requests, r)
+		}
+	}
+	return requests
+}
+
+// LastRequest returns last captured request
+func (s *Server) LastRequest() (CapturedRequest, bool) {
+	s.locker.RLock()
+	defer s.locker.RUnlock()
+
+	if len(s.requests) == 0 {
+		return CapturedRequest{}, false
+	}
+	return s.requests[len(s.requests)-1], true
+}
+
+// Reset removes routes and captured requests
+func (s *Server) Reset() {
+	s.locker.Lock()
+	s.routes = nil
+	s.requests = nil
+	s.locker.Unlock()
+}
+
+// serve captures request and writes response of matched route
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	s.locker.Lock()
+	s.requests = append(s.requests, CapturedRequest{
+		Method:  r.Method,
+		Host:    r.Host,
+		Path:    r.URL.Path,
+		Query:   r.URL.Query(),
+		Header:  r.Header.Clone(),
+		Cookies: r.Cookies(),
+		Body:    body,
+		Time:    time.Now(),
+	})
+	var route *Route
+	for _, item := range s.routes {
+		if item.match(r) {
+			route = item
+			break
+		}
+	}
+	s.locker.Unlock()
+
+	if route == nil {
+		http.Error(w, "clienttest: no route for "+r.Method+" "+r.URL.Path, http.StatusNotFound)
+		return
+	}
+	route.next().write(w, r)
+}
+
+// Route is a matched request and its scripted responses
+type Route struct {
+	method    string
+	path      string
+	matchers  []func(*http.Request) bool
+	responses []Response
+	latency   time.Duration
+	calls     int
+	locker    sync.Mutex
+}
+
+// Response is a scripted response
+type Response struct {
+	Status   int
+	Body     []byte
+	Header   http.Header
+	Cookies  []*http.Cookie
+	Latency  time.Duration
+	Location string
+}
+
+// Match adds custom request matcher
+func (route *Route) Match(fn func(*http.Request) bool) *Route {
+	route.matchers = append(route.matchers, fn)
+	return route
+}
+
+// WithQuery matches query param value
+func (route *Route) WithQuery(key, value string) *Route {
+	return route.Match(func(r *http.Request) bool { return r.URL.Query().Get(key) == value })
+}
+
+// WithHeader matches header value
+func (route *Route) WithHeader(key, value string) *Route {
+	return route.Match(func(r *http.Request) bool { return r.Header.Get(key) == value })
+}
+
+// Latency delays every response of route
+func (route *Route) Latency(d time.Duration) *Route {
+	route.latency = d
+	return route
+}
+
+// Then appends response to sequence, the last response repeats
+func (route *Route) Then(resp Response) *Route {
+	route.locker.Lock()
+	route.responses = append(route.responses, resp)
+	route.locker.Unlock()
+	return route
+}
+
+// Respond appends response with body to sequence
+func (route *Route) Respond(status int, body string) *Route {
+	return route.Then(Response{Status: status, Body: []byte(body)})
+}
+
+// RespondJSON appends json response to sequence
+func (route *Route) RespondJSON(status int, v any) *Route {
+	body, _ := json.Marshal(v)
+	return route.Then(Response{
+		Status: status,
+		Body:   body,
+		Header: http.Header{"Content-Type": []string{"application/json"}},
+	})
+}
+
+// Fail appends n responses with status to sequence, e.g. Fail(2, 502).Respond(200, "ok")
+func (route *Route) Fail(n int, status int) *Route {
+	for i := 0; i < n; i++ {
+		route.Respond(status, http.StatusText(status))
+	}
+	return route
+}
+
+// Redirect appends redirect response to sequence
+func (route *Route) Redirect(status int, location string) *Route {
+	return route.Then(Response{Status: status, Location: location})
+}
+
+// SetCookie sets cookie on the last response of sequence, or on a new 200 response
+func (route *Route) SetCookie(c *http.Cookie) *Route {
+	route.locker.Lock()
+	defer route.locker.Unlock()
+
+	if len(route.responses) == 0 {
+		route.responses = append(route.responses, Response{Status: http.StatusOK})
+	}
+	last := &route.responses[len(route.responses)-1]
+	last.Cookies = append(last.Cookies, c)
+	return route
+}
+
+// Calls returns count of served requests
+func (route *Route) Calls() int {
+	route.locker.Lock()
+	defer route.locker.Unlock()
+
+	return route.calls
+}
+
+// match reports whether request matches route
+func (route *Route) match(r *http.Request) bool {
+	if route.method != "" && route.method != "*" && route.method != r.Method {
+		return false
+	}
+	if strings.HasSuffix(route.path, "*") {
+		if !strings.HasPrefix(r.URL.Path, strings.TrimSuffix(route.path, "*")) {
+			return false
+		}
+	} else if route.path != r.URL.Path {
+		return false
+	}
+	for _, fn := range route.matchers {
+		if !fn(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// next returns next response of sequence
+func (route *Route) next() Response {
+	route.locker.Lock()
+	defer route.locker.Unlock()
+
+	resp := Response{Status: http.StatusOK}
+	if len(route.responses) > 0 {
+		index := route.calls
+		if index >= len(route.responses) {
+			index = len(route.responses) - 1
+		}
+		resp = route.responses[index]
+	}
+	route.calls++
+	resp.Latency += route.latency
+
+	return resp
+}
+
+// write writes response
+func (resp Response) write(w http.ResponseWriter, r *http.Request) {
+	if resp.Latency > 0 {
+		select {
+		case <-time.After(resp.Latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	for key, values := range resp.Header {
+		for _, value := range values {
+			w.Header().Add(key, value)
+		}
+	}
+	for _, c := range resp.Cookies {
+		http.SetCookie(w, c)
+	}
+	if resp.Location != "" {
+		http.Redirect(w, r, resp.Location, resp.Status)
+		return
+	}
+
+	status := resp.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+	_, _ = w.Write(resp.Body)
+}
@@ -0,0 +1,55 @@
+package clienttest
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-per/simpkg/client"
+)
+
+func TestServer(t *testing.T) {
+	for _, s := range []*Server{NewServer(), NewTLSServer()} {
+		s.Handle(http.MethodGet, "/flaky").Fail(2, http.StatusBadGateway).Respond(http.StatusOK, "ok")
+		s.Handle(http.MethodPost, "/login").WithQuery("v", "2").SetCookie(&http.Cookie{Name: "sid", Value: "1"}).RespondJSON(http.StatusOK, map[string]string{"ok": "1"})
+		s.Handle("*", "/slow/*").Latency(time.Millisecond*30).Respond(http.StatusOK, "slow")
+		s.RedirectChain(http.StatusFound, "/a", "/b", "/c")
+		s.Handle(http.MethodGet, "/c").Respond(http.StatusOK, "end")
+
+		c := client.New()
+		s.Attach(c)
+		host := "https://api.example.com"
+
+		var statuses []int
+		for i := 0; i < 3; i++ {
+			resp, err := c.R().Get(host + "/flaky")
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			statuses = append(statuses, resp.StatusCode)
+		}
+		if statuses[0] != http.StatusBadGateway || statuses[1] != http.StatusBadGateway || statuses[2] != http.StatusOK {
+			t.Errorf("statuses = %v, want 502 502 200", statuses)
+		}
+
+		if _, err := c.R().SetBody("user=1").Post(host + "/login?v=2"); err != nil {
+			t.Fatalf("Post() error = %v", err)
+		}
+		if resp, _ := c.R().Get(host + "/a"); resp.String() != "end" {
+			t.Errorf("redirect chain body = %v, want end", resp.String())
+		}
+		last, _ := s.LastRequest()
+		if last.Host != "api.example.com" || len(last.Cookies) != 1 || last.Cookies[0].Value != "1" {
+			t.Errorf("LastRequest() host = %v, cookies = %v", last.Host, last.Cookies)
+		}
+		if login := s.RequestsTo("/login"); len(login) != 1 || string(login[0].Body) != "user=1" {
+			t.Errorf("RequestsTo(/login) = %v", login)
+		}
+
+		start := time.Now()
+		if resp, _ := c.R().Get(host + "/slow/1"); resp.String() != "slow" || time.Since(start) < time.Millisecond*30 {
+			t.Errorf("latency route body = %v after %v", resp.String(), time.Since(start))
+		}
+		s.Close()
+	}
+}
@@ -0,0 +1,366 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-per/simpkg/encryption"
+	"github.com/go-per/simpkg/helpers"
+	"github.com/go-per/simpkg/parse"
+	"golang.org/x/net/publicsuffix"
+)
+
+// Cookie is a persisted cookie
+type Cookie struct {
+	Host     string    `json:"host"`
+	Name     string    `json:"name"`
+	Value    string    `json:"value"`
+	Domain   string    `json:"domain,omitempty"`
+	Path     string    `json:"path,omitempty"`
+	Expires  time.Time `json:"expires,omitempty"`
+	Secure   bool      `json:"secure,omitempty"`
+	HttpOnly bool      `json:"http_only,omitempty"`
+}
+
+// CookieJar is a http cookie jar which persists cookies to disk,
+// domain and path matching are done by net/http/cookiejar
+type CookieJar struct {
+	path      string
+	encryptor encryption.IEncryptor
+	autoSave  bool
+	saveDelay time.Duration
+	saveTimer *time.Timer
+	jar       *cookiejar.Jar
+	cookies   map[string]Cookie
+	locker    sync.RWMutex
+
+	saveLocker sync.Mutex // orders snapshots and writes of Save
+}
+
+// NewCookieJar returns cookie jar persisted at path and loads saved cookies,
+// cookies are encrypted at rest when encryptor is given
+func NewCookieJar(path string, encryptor ...encryption.IEncryptor) (*CookieJar, error) {
+	j := &CookieJar{
+		path:      path,
+		autoSave:  true,
+		saveDelay: time.Second,
+		cookies:   make(map[string]Cookie),
+		locker:    sync.RWMutex{},
+	}
+	if len(encryptor) > 0 {
+		j.encryptor = encryptor[0]
+	}
+	j.jar = newJar()
+
+	return j, j.Load()
+}
+
+// newJar returns empty net/http cookie jar
+func newJar() *cookiejar.Jar {
+	jar, _ := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
+	return jar
+}
+
+// SetAutoSave enables or disables saving cookie changes after save delay
+func (j *CookieJar) SetAutoSave(v bool) {
+	j.locker.Lock()
+	j.autoSave = v
+	j.locker.Unlock()
+}
+
+// SetSaveDelay sets how long cookie changes are collected before they are saved, zero saves every change
+func (j *CookieJar) SetSaveDelay(d time.Duration) {
+	j.locker.Lock()
+	j.saveDelay = d
+	j.locker.Unlock()
+}
+
+// SetCookies implements http.CookieJar
+func (j *CookieJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.locker.Lock()
+	j.jar.SetCookies(u, cookies)
+
+	now := time.Now()
+	for _, c := range cookies {
+		cookie := Cookie{
+			Host:     u.Hostname(),
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   strings.TrimPrefix(strings.ToLower(c.Domain), "."),
+			Path:     c.Path,
+			Secure:   c.Secure,
+			HttpOnly: c.HttpOnly,
+		}
+		if cookie.Path == "" || cookie.Path[0] != '/' {
+			cookie.Path = defaultCookiePath(u.Path)
+		}
+		switch {
+		case c.MaxAge > 0:
+			cookie.Expires = now.Add(time.Duration(c.MaxAge) * time.Second)
+		case c.MaxAge < 0:
+			cookie.Expires = now
+		case !c.Expires.IsZero():
+			cookie.Expires = c.Expires
+		}
+
+		key := cookie.key()
+		if cookie.expired(now) {
+			delete(j.cookies, key)
+			continue
+		}
+		if !accepted(u, c, cookie) {
+			continue
+		}
+		j.cookies[key] = cookie
+	}
+	save := j.autoSave && j.saveDelay <= 0
+	if j.autoSave && j.saveDelay > 0 && j.saveTimer == nil {
+		j.saveTimer = time.AfterFunc(j.saveDelay, func() { _ = j.Flush(context.Background()) })
+	}
+	j.locker.Unlock()
+
+	if save {
+		_ = j.Save()
+	}
+}
+
+// Cookies implements http.CookieJar
+func (j *CookieJar) Cookies(u *url.URL) []*http.Cookie {
+	j.locker.RLock()
+	defer j.locker.RUnlock()
+
+	return j.jar.Cookies(u)
+}
+
+// Domains returns sorted domains which have cookies
+func (j *CookieJar) Domains() []string {
+	j.locker.RLock()
+	seen := make(map[string]bool)
+	for _, c := range j.cookies {
+		seen[c.domain()] = true
+	}
+	j.locker.RUnlock()
+
+	domains := make([]string, 0, len(seen))
+	for domain := range seen {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+
+	return domains
+}
+
+// DomainCookies returns not expired cookies of domain and its subdomains
+func (j *CookieJar) DomainCookies(domain string) []Cookie {
+	j.locker.RLock()
+	defer j.locker.RUnlock()
+
+	now := time.Now()
+	cookies := make([]Cookie, 0)
+	for _, c := range j.cookies {
+		if c.matchDomain(domain) && !c.expired(now) {
+			cookies = append(cookies, c)
+		}
+	}
+	sort.Slice(cookies, func(a, b int) bool { return cookies[a].key() < cookies[b].key() })
+
+	return cookies
+}
+
+// ClearDomain removes cookies of domain and its subdomains
+func (j *CookieJar) ClearDomain(domain string) error {
+	j.locker.Lock()
+	for key, c := range j.cookies {
+		if c.matchDomain(domain) {
+			delete(j.cookies, key)
+		}
+	}
+	j.rebuild()
+	j.locker.Unlock()
+
+	return j.Save()
+}
+
+// Clear removes all cookies
+func (j *CookieJar) Clear() error {
+	j.locker.Lock()
+	j.cookies = make(map[string]Cookie)
+	j.rebuild()
+	j.locker.Unlock()
+
+	return j.Save()
+}
+
+// Load loads cookies from disk, expired cookies are dropped
+func (j *CookieJar) Load() error {
+	if !helpers.IsExists(j.path) {
+		return nil
+	}
+
+	content, err := helpers.ReadFile(j.path)
+	if err != nil {
+		return err
+	}
+	if j.encryptor != nil {
+		text, err := j.encryptor.Decrypt(string(content))
+		if err != nil {
+			return err
+		}
+		content = []byte(text)
+	}
+
+	cookies := make(map[string]Cookie)
+	list := make([]Cookie, 0)
+	if err = parse.Decode(content, &list); err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, c := range list {
+		if !c.expired(now) {
+			cookies[c.key()] = c
+		}
+	}
+
+	j.locker.Lock()
+	j.cookies = cookies
+	j.rebuild()
+	j.locker.Unlock()
+
+	return nil
+}
+
+// Save writes not expired cookies to disk atomically, concurrent saves are written in order
+func (j *CookieJar) Save() error {
+	j.saveLocker.Lock()
+	defer j.saveLocker.Unlock()
+
+	j.locker.RLock()
+	now := time.Now()
+	list := make([]Cookie, 0, len(j.cookies))
+	for _, c := range j.cookies {
+		if !c.expired(now) {
+			list = append(list, c)
+		}
+	}
+	j.locker.RUnlock()
+	sort.Slice(list, func(a, b int) bool { return list[a].key() < list[b].key() })
+
+	content, err := parse.Encode(list)
+	if err != nil {
+		return err
+	}
+	if j.encryptor != nil {
+		text, err := j.encryptor.Encrypt(string(content))
+		if err != nil {
+			return err
+		}
+		content = []byte(text)
+	}
+
+	return helpers.WriteFileAtomic(j.path, content)
+}
+
+// Flush saves pending cookie changes
+func (j *CookieJar) Flush(ctx context.Context) error {
+	j.locker.Lock()
+	pending := j.saveTimer != nil
+	if pending {
+		j.saveTimer.Stop()
+		j.saveTimer = nil
+	}
+	j.locker.Unlock()
+
+	if !pending {
+		return nil
+	}
+	return j.Save()
+}
+
+// rebuild recreates net/http jar from cookies, caller must hold the lock
+func (j *CookieJar) rebuild() {
+	j.jar = newJar()
+	for _, c := range j.cookies {
+		scheme := "http"
+		if c.Secure {
+			scheme = "https"
+		}
+		u := &url.URL{Scheme: scheme, Host: c.Host, Path: c.Path}
+		j.jar.SetCookies(u, []*http.Cookie{c.httpCookie()})
+	}
+}
+
+// accepted reports whether net/http jar accepts cookie c set by u, e.g. cookies of
+// public suffixes or foreign domains are rejected
+func accepted(u *url.URL, c *http.Cookie, cookie Cookie) bool {
+	probe := newJar()
+	probe.SetCookies(u, []*http.Cookie{c})
+
+	target := &url.URL{Scheme: "https", Host: u.Host, Path: cookie.Path}
+	for _, got := range probe.Cookies(target) {
+		if got.Name == c.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// key returns unique cookie key
+func (c Cookie) key() string {
+	return c.domain() + ";" + c.Path + ";" + c.Name
+}
+
+// domain returns cookie domain, host for host only cookies
+func (c Cookie) domain() string {
+	if c.Domain != "" {
+		return c.Domain
+	}
+	return strings.ToLower(c.Host)
+}
+
+// matchDomain reports whether cookie belongs to domain or its subdomains
+func (c Cookie) matchDomain(domain string) bool {
+	domain = strings.TrimPrefix(strings.ToLower(domain), ".")
+	d := c.domain()
+	return d == domain || strings.HasSuffix(d, "."+domain)
+}
+
+// expired reports whether cookie is expired, session cookies never expire
+func (c Cookie) expired(now time.Time) bool {
+	return !c.Expires.IsZero() && !c.Expires.After(now)
+}
+
+// httpCookie returns net/http cookie
+func (c Cookie) httpCookie() *http.Cookie {
+	return &http.Cookie{
+		Name:     c.Name,
+		Value:    c.Value,
+		Domain:   c.Domain,
+		Path:     c.Path,
+		Expires:  c.Expires,
+		Secure:   c.Secure,
+		HttpOnly: c.HttpOnly,
+	}
+}
+
+// defaultCookiePath returns default cookie path of request path, RFC 6265 section 5.1.4
+func defaultCookiePath(p string) string {
+	if p == "" || p[0] != '/' {
+		return "/"
+	}
+	i := strings.LastIndex(p, "/")
+	if i == 0 {
+		return "/"
+	}
+	return p[:i]
+}
+
+// WithCookieJar sets client cookie jar
+func WithCookieJar(jar http.CookieJar) Option {
+	return func(o *Options) { o.CookieJar = jar }
+}
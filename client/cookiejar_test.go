@@ -0,0 +1,145 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-per/simpkg/encryption"
+	"github.com/go-per/simpkg/helpers"
+)
+
+func TestCookieJar(t *testing.T) {
+	encryptor := encryption.New()
+	encryptor.SetKey([]byte("0123456789abcdef"))
+
+	tests := []struct {
+		name      string
+		encryptor []encryption.IEncryptor
+	}{
+		{name: "plain"},
+		{name: "encrypted", encryptor: []encryption.IEncryptor{encryptor}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "cookies.json")
+			jar, err := NewCookieJar(path, tt.encryptor...)
+			if err != nil {
+				t.Fatalf("NewCookieJar() error = %v", err)
+			}
+
+			u, _ := url.Parse("https://www.example.com/account/login")
+			jar.SetCookies(u, []*http.Cookie{
+				{Name: "session", Value: "1", Domain: "example.com", Path: "/"},
+				{Name: "remember", Value: "2", MaxAge: 3600},
+				{Name: "old", Value: "3", Expires: time.Now().Add(-time.Hour)},
+				{Name: "suffix", Value: "5", Domain: "com"},
+				{Name: "foreign", Value: "6", Domain: "other.org"},
+			})
+			other, _ := url.Parse("https://other.org/")
+			jar.SetCookies(other, []*http.Cookie{{Name: "id", Value: "4"}})
+			if err = jar.Flush(context.Background()); err != nil {
+				t.Fatalf("Flush() error = %v", err)
+			}
+
+			loaded, err := NewCookieJar(path, tt.encryptor...)
+			if err != nil {
+				t.Fatalf("NewCookieJar() reload error = %v", err)
+			}
+			if got := len(loaded.Cookies(u)); got != 2 {
+				t.Errorf("Cookies() = %v cookies, want 2", got)
+			}
+			if got := loaded.Domains(); len(got) != 3 {
+				t.Errorf("Domains() = %v, want rejected cookies not persisted", got)
+			}
+			if got := len(loaded.DomainCookies("example.com")); got != 2 {
+				t.Errorf("DomainCookies() = %v cookies, want 2", got)
+			}
+
+			if err = loaded.ClearDomain("example.com"); err != nil {
+				t.Fatalf("ClearDomain() error = %v", err)
+			}
+			if got := len(loaded.Cookies(u)); got != 0 {
+				t.Errorf("Cookies() after ClearDomain = %v cookies, want 0", got)
+			}
+			if got := loaded.Domains(); len(got) != 1 || got[0] != "other.org" {
+				t.Errorf("Domains() = %v, want [other.org]", got)
+			}
+		})
+	}
+}
+
+func TestCookieJar_ConcurrentSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cookies.json")
+	jar, err := NewCookieJar(path)
+	if err != nil {
+		t.Fatalf("NewCookieJar() error = %v", err)
+	}
+	jar.SetSaveDelay(0)
+
+	u, _ := url.Parse("https://example.com/")
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			jar.SetCookies(u, []*http.Cookie{{Name: "c" + strconv.Itoa(i), Value: "1"}})
+		}(i)
+	}
+	wg.Wait()
+
+	loaded, err := NewCookieJar(path)
+	if err != nil {
+		t.Fatalf("NewCookieJar() reload error = %v", err)
+	}
+	if got := len(loaded.Cookies(u)); got != 20 {
+		t.Errorf("Cookies() after concurrent saves = %v cookies, want 20", got)
+	}
+}
+
+func TestCookieJar_SaveDelay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cookies.json")
+	jar, err := NewCookieJar(path)
+	if err != nil {
+		t.Fatalf("NewCookieJar() error = %v", err)
+	}
+	jar.SetSaveDelay(time.Millisecond * 50)
+
+	u, _ := url.Parse("https://example.com/")
+	for i := 0; i < 10; i++ {
+		jar.SetCookies(u, []*http.Cookie{{Name: "c" + strconv.Itoa(i), Value: "1"}})
+	}
+	if helpers.IsExists(path) {
+		t.Fatalf("cookies are saved before save delay")
+	}
+
+	// changes are saved once after delay
+	deadline := time.Now().Add(time.Second)
+	for !helpers.IsExists(path) && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond * 5)
+	}
+	loaded, err := NewCookieJar(path)
+	if err != nil {
+		t.Fatalf("NewCookieJar() reload error = %v", err)
+	}
+	if got := len(loaded.Cookies(u)); got != 10 {
+		t.Errorf("Cookies() after save delay = %v cookies, want 10", got)
+	}
+
+	// flush saves pending changes at once
+	jar.SetCookies(u, []*http.Cookie{{Name: "last", Value: "1"}})
+	if err = jar.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if err = loaded.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := len(loaded.Cookies(u)); got != 11 {
+		t.Errorf("Cookies() after Flush() = %v cookies, want 11", got)
+	}
+}
@@ -0,0 +1,89 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-per/simpkg/str"
+	"github.com/imroc/req/v3"
+	"golang.org/x/net/html/charset"
+)
+
+// DecodeMode is a set of response body decoding steps
+type DecodeMode int
+
+const (
+	DecodeNone    DecodeMode = 0
+	DecodeCharset DecodeMode = 1 << 0 // convert body to utf-8 by header, meta tag or bom charset
+	DecodePersian DecodeMode = 1 << 1 // normalize arabic letters to persian
+	DecodeAll                = DecodeCharset | DecodePersian
+)
+
+// decodeModeKey is context key of request decode mode
+type decodeModeKey struct{}
+
+// WithDecode sets default response decoding of client, charset decoding replaces req auto decode
+func WithDecode(mode DecodeMode) Option {
+	return func(o *Options) { o.Decode = mode }
+}
+
+// SetRequestDecode overrides response decoding of request
+func SetRequestDecode(r *req.Request, mode DecodeMode) *req.Request {
+	return r.SetContext(context.WithValue(r.Context(), decodeModeKey{}, mode))
+}
+
+// DecodeBody converts text body to utf-8 and normalizes it by mode, binary bodies are returned as is
+func DecodeBody(body []byte, contentType string, mode DecodeMode) ([]byte, error) {
+	if mode == DecodeNone || len(body) == 0 || !isTextContent(contentType) {
+		return body, nil
+	}
+
+	if mode&DecodeCharset != 0 {
+		// valid utf-8 is kept, it is either utf-8 or already decoded
+		if enc, name, _ := charset.DetermineEncoding(body, contentType); name != "utf-8" && !utf8.Valid(body) {
+			decoded, err := io.ReadAll(enc.NewDecoder().Reader(bytes.NewReader(body)))
+			if err != nil {
+				return body, err
+			}
+			body = decoded
+		}
+		body = bytes.TrimPrefix(body, []byte("\xef\xbb\xbf"))
+	}
+	if mode&DecodePersian != 0 {
+		body = []byte(str.ArabicToPersian(string(body)))
+	}
+
+	return body, nil
+}
+
+// setDecoder registers response body decoder on client
+func setDecoder(c *req.Client, mode DecodeMode) {
+	c.SetResponseBodyTransformer(func(body []byte, r *req.Request, resp *req.Response) ([]byte, error) {
+		m := mode
+		if v, ok := r.Context().Value(decodeModeKey{}).(DecodeMode); ok {
+			m = v
+		}
+		if m == DecodeNone {
+			return body, nil
+		}
+
+		return DecodeBody(body, resp.GetContentType(), m)
+	})
+}
+
+// isTextContent reports whether content type is text, empty content type is sniffed as text
+func isTextContent(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	if contentType == "" || strings.HasPrefix(contentType, "text/") {
+		return true
+	}
+	for _, t := range []string{"json", "xml", "html", "javascript", "x-www-form-urlencoded"} {
+		if strings.Contains(contentType, t) {
+			return true
+		}
+	}
+	return false
+}
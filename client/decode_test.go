@@ -0,0 +1,52 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	// "سلام كيف" in windows-1256, with arabic kaf
+	win1256 := []byte{0xD3, 0xE1, 0xC7, 0xE3, 0x20, 0xDF, 0xED, 0xDD}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/header":
+			w.Header().Set("Content-Type", "text/plain; charset=windows-1256")
+			_, _ = w.Write(win1256)
+		case "/meta":
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write(append([]byte(`<html><head><meta charset="windows-1256"></head><body>`), win1256...))
+		case "/bom":
+			w.Header().Set("Content-Type", "text/plain")
+			_, _ = w.Write([]byte("\xef\xbb\xbfسلام"))
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name string
+		path string
+		mode DecodeMode
+		want string
+	}{
+		{name: "header charset", path: "/header", mode: DecodeCharset, want: "سلام كيف"},
+		{name: "persian", path: "/header", mode: DecodeAll, want: "سلام کیف"},
+		{name: "meta charset", path: "/meta", mode: DecodeCharset, want: `<html><head><meta charset="windows-1256"></head><body>سلام كيف`},
+		{name: "bom", path: "/bom", mode: DecodeCharset, want: "سلام"},
+		{name: "raw", path: "/bom", mode: DecodeNone, want: "\xef\xbb\xbfسلام"},
+	}
+	c := NewWithOptions(WithDecode(DecodeCharset))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := SetRequestDecode(c.R(), tt.mode).Get(server.URL + tt.path)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			if got := resp.String(); got != tt.want {
+				t.Errorf("Get() body = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
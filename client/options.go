@@ -0,0 +1,298 @@
+package client
+
+import (
+	"crypto/tls"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-per/simpkg/format"
+	"github.com/go-per/simpkg/useragent"
+	"github.com/imroc/req/v3"
+)
+
+// HTTPVersion type
+type HTTPVersion string
+
+const (
+	HTTPAuto HTTPVersion = ""
+	HTTP1    HTTPVersion = "1"
+	HTTP2    HTTPVersion = "2"
+	HTTP3    HTTPVersion = "3"
+)
+
+// Preset is a named list of options
+type Preset string
+
+const (
+	PresetBrowser Preset = "browser"
+	PresetAPI     Preset = "api"
+	PresetStrict  Preset = "strict"
+)
+
+// Options struct
+type Options struct {
+	InsecureSkipVerify bool
+	MinTLSVersion      uint16
+	RootCAFiles        []string
+	RootCAPem          string
+	Timeout            time.Duration
+	MaxConnsPerHost    int
+	MaxIdleConns       int
+	IdleConnTimeout    time.Duration
+	HTTPVersion        HTTPVersion
+	Proxy              string
+	CookieJar          http.CookieJar
+	RateLimiter        *RateLimiter
+	CircuitBreaker     *CircuitBreaker
+	BreakerOwner       string
+	RedirectPolicies   []req.RedirectPolicy
+	UserAgent          func() string
+	Profile            *Profile
+	Logger             req.Logger
+	DisableAutoDecode  bool
+	Decode             DecodeMode
+	DisableKeepAlives  bool
+	Configure          []func(*req.Client)
+}
+
+// Option configures client options
+type Option func(*Options)
+
+// presetsLocker guards presets, clients are created concurrently
+var presetsLocker sync.RWMutex
+
+// presets keeps registered presets
+var presets = map[Preset][]Option{
+	PresetBrowser: {
+		WithInsecureSkipVerify(true),
+		WithRandomProfile(),
+		WithAutoDecode(false),
+		WithKeepAlives(true),
+	},
+	PresetAPI: {
+		WithInsecureSkipVerify(false),
+		WithAutoDecode(true),
+		WithKeepAlives(true),
+		WithTimeout(time.Second * 30),
+	},
+	PresetStrict: {
+		WithInsecureSkipVerify(false),
+		WithMinTLSVersion(tls.VersionTLS12),
+		WithAutoDecode(true),
+		WithKeepAlives(true),
+		WithTimeout(time.Second * 30),
+		WithRedirectPolicy(req.MaxRedirectPolicy(5), req.SameDomainRedirectPolicy()),
+	},
+}
+
+// NewWithOptions creates new http client by options
+func NewWithOptions(opts ...Option) *req.Client {
+	o := &Options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o.build()
+}
+
+// NewFromPreset creates new http client by preset and extra options
+func NewFromPreset(name Preset, opts ...Option) (*req.Client, error) {
+	presetOpts, ok := PresetOptions(name)
+	if !ok {
+		return nil, format.Error("client preset not found [%v]", name)
+	}
+
+	return NewWithOptions(append(presetOpts, opts...)...), nil
+}
+
+// RegisterPreset registers or replaces a named preset
+func RegisterPreset(name Preset, opts ...Option) {
+	presetsLocker.Lock()
+	presets[name] = append([]Option{}, opts...)
+	presetsLocker.Unlock()
+}
+
+// PresetOptions returns options of preset
+func PresetOptions(name Preset) ([]Option, bool) {
+	presetsLocker.RLock()
+	defer presetsLocker.RUnlock()
+
+	opts, ok := presets[name]
+	if !ok {
+		return nil, false
+	}
+
+	return append([]Option{}, opts...), true
+}
+
+// WithInsecureSkipVerify disables tls certificate verification
+func WithInsecureSkipVerify(v bool) Option {
+	return func(o *Options) { o.InsecureSkipVerify = v }
+}
+
+// WithMinTLSVersion sets minimum tls version
+func WithMinTLSVersion(v uint16) Option {
+	return func(o *Options) { o.MinTLSVersion = v }
+}
+
+// WithRootCAFiles sets custom CA bundle files
+func WithRootCAFiles(files ...string) Option {
+	return func(o *Options) { o.RootCAFiles = append(o.RootCAFiles, files...) }
+}
+
+// WithRootCAPem sets custom CA bundle content
+func WithRootCAPem(pem string) Option {
+	return func(o *Options) { o.RootCAPem = pem }
+}
+
+// WithTimeout sets request timeout
+func WithTimeout(d time.Duration) Option {
+	return func(o *Options) { o.Timeout = d }
+}
+
+// WithConnectionPool sets connection pool limits
+func WithConnectionPool(maxConnsPerHost, maxIdleConns int, idleTimeout time.Duration) Option {
+	return func(o *Options) {
+		o.MaxConnsPerHost = maxConnsPerHost
+		o.MaxIdleConns = maxIdleConns
+		o.IdleConnTimeout = idleTimeout
+	}
+}
+
+// WithHTTPVersion sets preferred http version
+func WithHTTPVersion(v HTTPVersion) Option {
+	return func(o *Options) { o.HTTPVersion = v }
+}
+
+// WithProxy sets proxy url
+func WithProxy(proxyUrl string) Option {
+	return func(o *Options) { o.Proxy = proxyUrl }
+}
+
+// WithRedirectPolicy sets redirect policies
+func WithRedirectPolicy(policies ...req.RedirectPolicy) Option {
+	return func(o *Options) { o.RedirectPolicies = policies }
+}
+
+// WithUserAgent sets fixed user agent
+func WithUserAgent(ua string) Option {
+	return func(o *Options) { o.UserAgent = func() string { return ua } }
+}
+
+// WithRandomUserAgent sets random user agent
+func WithRandomUserAgent() Option {
+	return func(o *Options) { o.UserAgent = useragent.Random }
+}
+
+// WithUserAgentFunc sets user agent strategy
+func WithUserAgentFunc(fn func() string) Option {
+	return func(o *Options) { o.UserAgent = fn }
+}
+
+// WithLogger sets client logger
+func WithLogger(l req.Logger) Option {
+	return func(o *Options) { o.Logger = l }
+}
+
+// WithAutoDecode enables or disables response auto decoding
+func WithAutoDecode(v bool) Option {
+	return func(o *Options) { o.DisableAutoDecode = !v }
+}
+
+// WithKeepAlives enables or disables keep alives
+func WithKeepAlives(v bool) Option {
+	return func(o *Options) { o.DisableKeepAlives = !v }
+}
+
+// WithConfigure calls fn with the built client
+func WithConfigure(fn func(*req.Client)) Option {
+	return func(o *Options) { o.Configure = append(o.Configure, fn) }
+}
+
+// build creates client by options
+func (o *Options) build() *req.Client {
+	c := req.NewClient()
+
+	if o.Profile != nil {
+		o.Profile.apply(c, o.HTTPVersion == HTTP1)
+	}
+	if o.UserAgent != nil {
+		c.SetUserAgent(o.UserAgent())
+	}
+	if o.DisableAutoDecode || o.Decode&DecodeCharset != 0 {
+		c.DisableAutoDecode()
+	}
+	setDecoder(c, o.Decode)
+	if o.DisableKeepAlives {
+		c.DisableKeepAlives()
+	} else {
+		c.EnableKeepAlives()
+	}
+
+	// tls
+	if o.InsecureSkipVerify {
+		c.EnableInsecureSkipVerify()
+	}
+	if o.MinTLSVersion > 0 {
+		c.GetTLSClientConfig().MinVersion = o.MinTLSVersion
+	}
+	if len(o.RootCAFiles) > 0 {
+		c.SetRootCertsFromFile(o.RootCAFiles...)
+	}
+	if o.RootCAPem != "" {
+		c.SetRootCertFromString(o.RootCAPem)
+	}
+
+	// transport
+	if o.Timeout > 0 {
+		c.SetTimeout(o.Timeout)
+	}
+	if o.MaxConnsPerHost > 0 {
+		c.GetTransport().SetMaxConnsPerHost(o.MaxConnsPerHost)
+	}
+	if o.MaxIdleConns > 0 {
+		c.GetTransport().SetMaxIdleConns(o.MaxIdleConns)
+	}
+	if o.IdleConnTimeout > 0 {
+		c.GetTransport().SetIdleConnTimeout(o.IdleConnTimeout)
+	}
+	switch o.HTTPVersion {
+	case HTTP1:
+		c.EnableForceHTTP1()
+	case HTTP2:
+		c.EnableForceHTTP2()
+	case HTTP3:
+		c.EnableHTTP3()
+	}
+	if o.Proxy != "" {
+		c.SetProxyURL(o.Proxy)
+	}
+	if limiter := o.RateLimiter; limiter != nil || SharedRateLimiter() != nil {
+		if limiter == nil {
+			limiter = SharedRateLimiter()
+		}
+		limiter.Attach(c)
+	}
+	if breaker := o.CircuitBreaker; breaker != nil || SharedCircuitBreaker() != nil {
+		if breaker == nil {
+			breaker = SharedCircuitBreaker()
+		}
+		breaker.Attach(c, o.BreakerOwner)
+	}
+	if o.CookieJar != nil {
+		c.SetCookieJar(o.CookieJar)
+	}
+	if len(o.RedirectPolicies) > 0 {
+		c.SetRedirectPolicy(o.RedirectPolicies...)
+	}
+	if o.Logger != nil {
+		c.SetLogger(o.Logger)
+	}
+
+	for _, fn := range o.Configure {
+		fn(c)
+	}
+
+	return c
+}
@@ -0,0 +1,98 @@
+package client
+
+import (
+	"encoding/pem"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRegisterPreset(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			RegisterPreset("test", WithTimeout(time.Second))
+		}()
+		go func() {
+			defer wg.Done()
+			_, _ = NewFromPreset(PresetAPI)
+		}()
+	}
+	wg.Wait()
+
+	if _, err := NewFromPreset("test"); err != nil {
+		t.Errorf("NewFromPreset() error = %v", err)
+	}
+	if _, err := NewFromPreset("missing"); err == nil {
+		t.Errorf("NewFromPreset() of missing preset error = nil")
+	}
+}
+
+func TestNewFromPreset(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			<-r.Context().Done()
+			return
+		}
+		_, _ = w.Write([]byte(r.Proto))
+	}))
+	server.EnableHTTP2 = true
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	defer server.Close()
+
+	caPem := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, []byte(caPem), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		preset    Preset
+		opts      []Option
+		path      string
+		wantProto string
+		wantErr   bool
+	}{
+		{"browser skips verification", PresetBrowser, nil, "/", "HTTP/2.0", false},
+		{"api verifies", PresetAPI, nil, "/", "", true},
+		{"strict verifies", PresetStrict, nil, "/", "", true},
+		{"api with ca pem", PresetAPI, []Option{WithRootCAPem(caPem)}, "/", "HTTP/2.0", false},
+		{"strict with ca file", PresetStrict, []Option{WithRootCAFiles(caFile)}, "/", "HTTP/2.0", false},
+		{"forced http1", PresetBrowser, []Option{WithHTTPVersion(HTTP1)}, "/", "HTTP/1.1", false},
+		{"forced http2", PresetAPI, []Option{WithRootCAPem(caPem), WithHTTPVersion(HTTP2)}, "/", "HTTP/2.0", false},
+		{"timeout", PresetAPI, []Option{WithRootCAPem(caPem), WithTimeout(time.Millisecond * 50)}, "/slow", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewFromPreset(tt.preset, tt.opts...)
+			if err != nil {
+				t.Fatalf("NewFromPreset() error = %v", err)
+			}
+			resp, err := c.R().Get(server.URL + tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Get() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && resp.String() != tt.wantProto {
+				t.Errorf("Get() proto = %v, want %v", resp.String(), tt.wantProto)
+			}
+		})
+	}
+
+	// preset timeouts
+	for preset, want := range map[Preset]time.Duration{PresetAPI: time.Second * 30, PresetStrict: time.Second * 30} {
+		c, _ := NewFromPreset(preset)
+		if got := c.GetClient().Timeout; got != want {
+			t.Errorf("NewFromPreset(%v) timeout = %v, want %v", preset, got, want)
+		}
+	}
+}
@@ -0,0 +1,451 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"hash/crc32"
+	"net"
+
+	"github.com/go-per/simpkg/random"
+	"github.com/imroc/req/v3"
+	"github.com/imroc/req/v3/http2"
+	utls "github.com/refraction-networking/utls"
+)
+
+// Browser type
+type Browser string
+
+const (
+	BrowserChrome  Browser = "chrome"
+	BrowserEdge    Browser = "edge"
+	BrowserFirefox Browser = "firefox"
+	BrowserSafari  Browser = "safari"
+)
+
+// Profile is a coherent browser identity, user agent, client hints,
+// default headers and their order, tls hello and http2 fingerprint are applied together
+// and belong to the same browser version
+type Profile struct {
+	Name        string
+	Browser     Browser
+	Platform    string
+	Mobile      bool
+	UserAgent   string
+	Headers     map[string]string
+	HeaderOrder []string // lower case header names in sending order
+	HTTP2       HTTP2Fingerprint
+	TLSHello    utls.ClientHelloID
+}
+
+// HTTP2Fingerprint is browser http2 connection preface and headers frame layout
+type HTTP2Fingerprint struct {
+	Settings          []http2.Setting
+	ConnectionFlow    uint32 // increment of initial connection window update
+	HeaderPriority    http2.PriorityParam
+	PriorityFrames    []http2.PriorityFrame
+	PseudoHeaderOrder []string
+}
+
+// utlsConn adapts utls connection state to crypto/tls
+type utlsConn struct {
+	*utls.UConn
+}
+
+// chromium client hints
+const (
+	chromeBrands = `"Not_A Brand";v="8", "Chromium";v="120", "Google Chrome";v="120"`
+	edgeBrands   = `"Not_A Brand";v="8", "Chromium";v="120", "Microsoft Edge";v="120"`
+)
+
+// chromiumHeaderOrder is chromium header order
+var chromiumHeaderOrder = []string{
+	"host",
+	"connection",
+	"cache-control",
+	"sec-ch-ua",
+	"sec-ch-ua-mobile",
+	"sec-ch-ua-platform",
+	"upgrade-insecure-requests",
+	"user-agent",
+	"accept",
+	"sec-fetch-site",
+	"sec-fetch-mode",
+	"sec-fetch-user",
+	"sec-fetch-dest",
+	"referer",
+	"accept-encoding",
+	"accept-language",
+	"cookie",
+}
+
+// chromiumHTTP2 is chromium http2 fingerprint
+var chromiumHTTP2 = HTTP2Fingerprint{
+	Settings: []http2.Setting{
+		{ID: http2.SettingHeaderTableSize, Val: 65536},
+		{ID: http2.SettingEnablePush, Val: 0},
+		{ID: http2.SettingMaxConcurrentStreams, Val: 1000},
+		{ID: http2.SettingInitialWindowSize, Val: 6291456},
+		{ID: http2.SettingMaxHeaderListSize, Val: 262144},
+	},
+	ConnectionFlow:    15663105,
+	HeaderPriority:    http2.PriorityParam{StreamDep: 0, Exclusive: true, Weight: 255},
+	PseudoHeaderOrder: []string{":method", ":authority", ":scheme", ":path"},
+}
+
+// chromiumHeaders returns chromium default headers
+func chromiumHeaders(brands, platform string, mobile bool) map[string]string {
+	m := "?0"
+	if mobile {
+		m = "?1"
+	}
+
+	return map[string]string{
+		"sec-ch-ua":                 brands,
+		"sec-ch-ua-mobile":          m,
+		"sec-ch-ua-platform":        `"` + platform + `"`,
+		"Accept":                    "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8,application/signed-exchange;v=b3;q=0.7",
+		"Accept-Encoding":           "gzip, deflate, br",
+		"Accept-Language":           "en-US,en;q=0.9",
+		"Upgrade-Insecure-Requests": "1",
+		"Sec-Fetch-Site":            "none",
+		"Sec-Fetch-Mode":            "navigate",
+		"Sec-Fetch-User":            "?1",
+		"Sec-Fetch-Dest":            "document",
+	}
+}
+
+// firefoxHeaderOrder is firefox header order
+var firefoxHeaderOrder = []string{
+	"host",
+	"user-agent",
+	"accept",
+	"accept-language",
+	"accept-encoding",
+	"referer",
+	"connection",
+	"cookie",
+	"upgrade-insecure-requests",
+	"sec-fetch-dest",
+	"sec-fetch-mode",
+	"sec-fetch-site",
+	"sec-fetch-user",
+	"te",
+}
+
+// firefoxHTTP2 is firefox http2 fingerprint
+var firefoxHTTP2 = HTTP2Fingerprint{
+	Settings: []http2.Setting{
+		{ID: http2.SettingHeaderTableSize, Val: 65536},
+		{ID: http2.SettingInitialWindowSize, Val: 131072},
+		{ID: http2.SettingMaxFrameSize, Val: 16384},
+	},
+	ConnectionFlow: 12517377,
+	HeaderPriority: http2.PriorityParam{StreamDep: 13, Exclusive: false, Weight: 41},
+	PriorityFrames: []http2.PriorityFrame{
+		{StreamID: 3, PriorityParam: http2.PriorityParam{StreamDep: 0, Weight: 200}},
+		{StreamID: 5, PriorityParam: http2.PriorityParam{StreamDep: 0, Weight: 100}},
+		{StreamID: 7, PriorityParam: http2.PriorityParam{StreamDep: 0, Weight: 0}},
+		{StreamID: 9, PriorityParam: http2.PriorityParam{StreamDep: 7, Weight: 0}},
+		{StreamID: 11, PriorityParam: http2.PriorityParam{StreamDep: 3, Weight: 0}},
+		{StreamID: 13, PriorityParam: http2.PriorityParam{StreamDep: 0, Weight: 240}},
+	},
+	PseudoHeaderOrder: []string{":method", ":path", ":authority", ":scheme"},
+}
+
+// firefoxHeaders returns firefox default headers
+func firefoxHeaders() map[string]string {
+	return map[string]string{
+		"Accept":                    "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,*/*;q=0.8",
+		"Accept-Encoding":           "gzip, deflate, br",
+		"Accept-Language":           "en-US,en;q=0.5",
+		"Upgrade-Insecure-Requests": "1",
+		"Sec-Fetch-Site":            "none",
+		"Sec-Fetch-Mode":            "navigate",
+		"Sec-Fetch-User":            "?1",
+		"Sec-Fetch-Dest":            "document",
+		"TE":                        "trailers",
+	}
+}
+
+// safariHeaderOrder is safari header order
+var safariHeaderOrder = []string{
+	"host",
+	"accept",
+	"sec-fetch-site",
+	"cookie",
+	"sec-fetch-dest",
+	"accept-language",
+	"sec-fetch-mode",
+	"user-agent",
+	"referer",
+	"accept-encoding",
+	"connection",
+}
+
+// safariHTTP2 is safari http2 fingerprint
+var safariHTTP2 = HTTP2Fingerprint{
+	Settings: []http2.Setting{
+		{ID: http2.SettingInitialWindowSize, Val: 4194304},
+		{ID: http2.SettingMaxConcurrentStreams, Val: 100},
+	},
+	ConnectionFlow:    10485760,
+	HeaderPriority:    http2.PriorityParam{StreamDep: 0, Exclusive: false, Weight: 254},
+	PseudoHeaderOrder: []string{":method", ":scheme", ":path", ":authority"},
+}
+
+// safariHeaders returns safari default headers
+func safariHeaders() map[string]string {
+	return map[string]string{
+		"Accept":          "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8",
+		"Accept-Encoding": "gzip, deflate, br",
+		"Accept-Language": "en-US,en;q=0.9",
+		"Sec-Fetch-Site":  "none",
+		"Sec-Fetch-Mode":  "navigate",
+		"Sec-Fetch-Dest":  "document",
+	}
+}
+
+// profiles list
+var profiles = []Profile{
+	{
+		Name:        "chrome-windows",
+		Browser:     BrowserChrome,
+		Platform:    "Windows",
+		UserAgent:   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+		Headers:     chromiumHeaders(chromeBrands, "Windows", false),
+		HeaderOrder: chromiumHeaderOrder,
+		HTTP2:       chromiumHTTP2,
+		TLSHello:    utls.HelloChrome_120,
+	},
+	{
+		Name:        "chrome-macos",
+		Browser:     BrowserChrome,
+		Platform:    "macOS",
+		UserAgent:   "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+		Headers:     chromiumHeaders(chromeBrands, "macOS", false),
+		HeaderOrder: chromiumHeaderOrder,
+		HTTP2:       chromiumHTTP2,
+		TLSHello:    utls.HelloChrome_120,
+	},
+	{
+		Name:        "chrome-android",
+		Browser:     BrowserChrome,
+		Platform:    "Android",
+		Mobile:      true,
+		UserAgent:   "Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36",
+		Headers:     chromiumHeaders(chromeBrands, "Android", true),
+		HeaderOrder: chromiumHeaderOrder,
+		HTTP2:       chromiumHTTP2,
+		TLSHello:    utls.HelloChrome_120,
+	},
+	{
+		// edge shares chromium network stack
+		Name:        "edge-windows",
+		Browser:     BrowserEdge,
+		Platform:    "Windows",
+		UserAgent:   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.0.0",
+		Headers:     chromiumHeaders(edgeBrands, "Windows", false),
+		HeaderOrder: chromiumHeaderOrder,
+		HTTP2:       chromiumHTTP2,
+		TLSHello:    utls.HelloChrome_120,
+	},
+	{
+		Name:        "firefox-windows",
+		Browser:     BrowserFirefox,
+		Platform:    "Windows",
+		UserAgent:   "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:120.0) Gecko/20100101 Firefox/120.0",
+		Headers:     firefoxHeaders(),
+		HeaderOrder: firefoxHeaderOrder,
+		HTTP2:       firefoxHTTP2,
+		TLSHello:    utls.HelloFirefox_120,
+	},
+	{
+		Name:        "firefox-linux",
+		Browser:     BrowserFirefox,
+		Platform:    "Linux",
+		UserAgent:   "Mozilla/5.0 (X11; Linux x86_64; rv:120.0) Gecko/20100101 Firefox/120.0",
+		Headers:     firefoxHeaders(),
+		HeaderOrder: firefoxHeaderOrder,
+		HTTP2:       firefoxHTTP2,
+		TLSHello:    utls.HelloFirefox_120,
+	},
+	{
+		Name:        "firefox-android",
+		Browser:     BrowserFirefox,
+		Platform:    "Android",
+		Mobile:      true,
+		UserAgent:   "Mozilla/5.0 (Android 13; Mobile; rv:120.0) Gecko/120.0 Firefox/120.0",
+		Headers:     firefoxHeaders(),
+		HeaderOrder: firefoxHeaderOrder,
+		HTTP2:       firefoxHTTP2,
+		TLSHello:    utls.HelloFirefox_120,
+	},
+	{
+		Name:        "safari-macos",
+		Browser:     BrowserSafari,
+		Platform:    "macOS",
+		UserAgent:   "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.6 Safari/605.1.15",
+		Headers:     safariHeaders(),
+		HeaderOrder: safariHeaderOrder,
+		HTTP2:       safariHTTP2,
+		TLSHello:    utls.HelloSafari_16_0,
+	},
+	{
+		Name:        "safari-ios",
+		Browser:     BrowserSafari,
+		Platform:    "iOS",
+		Mobile:      true,
+		UserAgent:   "Mozilla/5.0 (iPhone; CPU iPhone OS 16_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.6 Mobile/15E148 Safari/604.1",
+		Headers:     safariHeaders(),
+		HeaderOrder: safariHeaderOrder,
+		HTTP2:       safariHTTP2,
+		TLSHello:    utls.HelloSafari_16_0,
+	},
+}
+
+// Profiles returns all browser profiles
+func Profiles() []Profile {
+	return append([]Profile{}, profiles...)
+}
+
+// ProfileByName returns profile by name
+func ProfileByName(name string) (Profile, bool) {
+	for _, p := range profiles {
+		if p.Name == name {
+			return p, true
+		}
+	}
+
+	return Profile{}, false
+}
+
+// RandomProfile returns random profile
+func RandomProfile() Profile {
+	return profiles[random.IntInRange(0, len(profiles))]
+}
+
+// ProfileFor returns the same profile for the same key, e.g. worker id
+func ProfileFor(key string) Profile {
+	index := crc32.ChecksumIEEE([]byte(key)) % uint32(len(profiles))
+	return profiles[index]
+}
+
+// WithProfile applies browser profile
+func WithProfile(p Profile) Option {
+	return func(o *Options) {
+		o.Profile = &p
+		o.UserAgent = func() string { return p.UserAgent }
+	}
+}
+
+// WithRandomProfile applies random browser profile
+func WithRandomProfile() Option {
+	return func(o *Options) {
+		WithProfile(RandomProfile())(o)
+	}
+}
+
+// apply sets profile headers, their order, http2 and tls fingerprint on client,
+// http1 only clients do not offer h2 in tls hello
+func (p *Profile) apply(c *req.Client, http1 bool) {
+	if len(p.Headers) > 0 {
+		// browser encodings are decoded by transport
+		c.SetCommonHeaders(p.Headers).EnableAutoDecompress()
+	}
+	if len(p.HeaderOrder) > 0 {
+		c.SetCommonHeaderOrder(p.HeaderOrder...)
+	}
+	p.HTTP2.apply(c)
+	if p.TLSHello.Client != "" {
+		setTLSHello(c, p.TLSHello, http1)
+	}
+}
+
+// apply sets http2 fingerprint on client
+func (f *HTTP2Fingerprint) apply(c *req.Client) {
+	if len(f.Settings) > 0 {
+		c.SetHTTP2SettingsFrame(f.Settings...)
+	}
+	if f.ConnectionFlow > 0 {
+		c.SetHTTP2ConnectionFlow(f.ConnectionFlow)
+	}
+	if f.HeaderPriority.Weight > 0 || f.HeaderPriority.StreamDep > 0 {
+		c.SetHTTP2HeaderPriority(f.HeaderPriority)
+	}
+	if len(f.PriorityFrames) > 0 {
+		c.SetHTTP2PriorityFrames(f.PriorityFrames...)
+	}
+	if len(f.PseudoHeaderOrder) > 0 {
+		c.SetCommonPseudoHeaderOder(f.PseudoHeaderOrder...)
+	}
+}
+
+// setTLSHello performs tls handshake by utls client hello,
+// unlike req SetTLSFingerprint it keeps client verification settings.
+// Browser hellos offer h2, http1 only clients offer http/1.1 alone
+func setTLSHello(c *req.Client, id utls.ClientHelloID, http1 bool) {
+	c.SetTLSHandshake(func(ctx context.Context, addr string, plainConn net.Conn) (net.Conn, *tls.ConnectionState, error) {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			host = addr
+		}
+
+		conf := c.GetTLSClientConfig()
+		helloID := id
+		if http1 {
+			helloID = utls.HelloCustom
+		}
+		conn := &utlsConn{utls.UClient(plainConn, &utls.Config{
+			ServerName:         host,
+			NextProtos:         conf.NextProtos,
+			RootCAs:            conf.RootCAs,
+			MinVersion:         conf.MinVersion,
+			InsecureSkipVerify: conf.InsecureSkipVerify,
+		}, helloID)}
+		if http1 {
+			if err = applyHTTP1Hello(conn.UConn, id); err != nil {
+				return nil, nil, err
+			}
+		}
+		if err = conn.HandshakeContext(ctx); err != nil {
+			return nil, nil, err
+		}
+
+		state := conn.ConnectionState()
+		return conn, &state, nil
+	})
+}
+
+// applyHTTP1Hello applies client hello of id which offers http/1.1 only, conn must be
+// created with utls.HelloCustom
+func applyHTTP1Hello(conn *utls.UConn, id utls.ClientHelloID) error {
+	spec, err := utls.UTLSIdToSpec(id)
+	if err != nil {
+		return err
+	}
+	for _, ext := range spec.Extensions {
+		if alpn, ok := ext.(*utls.ALPNExtension); ok {
+			alpn.AlpnProtocols = []string{"http/1.1"}
+		}
+	}
+	return conn.ApplyPreset(&spec)
+}
+
+// ConnectionState returns crypto/tls connection state
+func (conn *utlsConn) ConnectionState() tls.ConnectionState {
+	cs := conn.Conn.ConnectionState()
+	return tls.ConnectionState{
+		Version:                     cs.Version,
+		HandshakeComplete:           cs.HandshakeComplete,
+		DidResume:                   cs.DidResume,
+		CipherSuite:                 cs.CipherSuite,
+		NegotiatedProtocol:          cs.NegotiatedProtocol,
+		NegotiatedProtocolIsMutual:  cs.NegotiatedProtocolIsMutual,
+		ServerName:                  cs.ServerName,
+		PeerCertificates:            cs.PeerCertificates,
+		VerifiedChains:              cs.VerifiedChains,
+		SignedCertificateTimestamps: cs.SignedCertificateTimestamps,
+		OCSPResponse:                cs.OCSPResponse,
+		TLSUnique:                   cs.TLSUnique,
+	}
+}
@@ -0,0 +1,280 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/imroc/req/v3/http2"
+	xhttp2 "golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+func TestNew_Profile(t *testing.T) {
+	var got http.Header
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header
+	}))
+	defer server.Close()
+
+	profile := ProfileFor("worker-1")
+	if ProfileFor("worker-1").Name != profile.Name {
+		t.Fatalf("ProfileFor() is not stable")
+	}
+
+	for _, p := range Profiles() {
+		if _, err := NewWithOptions(WithInsecureSkipVerify(true), WithProfile(p)).R().Get(server.URL); err != nil {
+			t.Errorf("Get() with profile %v error = %v", p.Name, err)
+		}
+	}
+
+	resp, err := New("worker-1").R().Get(server.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Get() status = %v", resp.StatusCode)
+	}
+	if ua := got.Get("User-Agent"); ua != profile.UserAgent {
+		t.Errorf("User-Agent = %v, want %v", ua, profile.UserAgent)
+	}
+	for key, value := range profile.Headers {
+		if got.Get(key) != value {
+			t.Errorf("%v = %v, want %v", key, got.Get(key), value)
+		}
+	}
+}
+
+func TestNew_ProfileHTTP1(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Proto))
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	tests := []struct {
+		version HTTPVersion
+		want    string
+	}{
+		{HTTPAuto, "HTTP/2.0"},
+		{HTTP1, "HTTP/1.1"},
+	}
+	for _, tt := range tests {
+		for _, p := range Profiles() {
+			c := NewWithOptions(WithInsecureSkipVerify(true), WithHTTPVersion(tt.version), WithProfile(p))
+			resp, err := c.R().Get(server.URL)
+			if err != nil {
+				t.Errorf("Get() with profile %v and http version %q error = %v", p.Name, tt.version, err)
+				continue
+			}
+			if got := resp.String(); got != tt.want {
+				t.Errorf("Get() with profile %v and http version %q proto = %v, want %v", p.Name, tt.version, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestNew_ProfileDecode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "deflate")
+		fw, _ := flate.NewWriter(w, flate.BestSpeed)
+		_, _ = fw.Write([]byte("hello"))
+		_ = fw.Close()
+	}))
+	defer server.Close()
+
+	for _, p := range Profiles() {
+		resp, err := NewWithOptions(WithProfile(p)).R().Get(server.URL)
+		if err != nil {
+			t.Fatalf("Get() with profile %v error = %v", p.Name, err)
+		}
+		if got := resp.String(); got != "hello" {
+			t.Errorf("Get() with profile %v body = %q, want %q", p.Name, got, "hello")
+		}
+	}
+}
+
+func TestNew_ProfileHeaderOrder(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	names := make(chan []string, 1)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			r := bufio.NewReader(conn)
+			var got []string
+			for {
+				line, err := r.ReadString('\n')
+				line = strings.TrimSpace(line)
+				if err != nil || line == "" {
+					break
+				}
+				if name, _, ok := strings.Cut(line, ":"); ok {
+					got = append(got, strings.ToLower(name))
+				}
+			}
+			names <- got
+			_, _ = conn.Write([]byte("HTTP/1.1 200 OK\r\nContent-Length: 0\r\nConnection: close\r\n\r\n"))
+			conn.Close()
+		}
+	}()
+
+	for _, p := range Profiles() {
+		c := NewWithOptions(WithHTTPVersion(HTTP1), WithProfile(p))
+		if _, err := c.R().Get("http://" + ln.Addr().String()); err != nil {
+			t.Fatalf("Get() with profile %v error = %v", p.Name, err)
+		}
+		if got := <-names; !inOrder(got, p.HeaderOrder, len(p.Headers)+2) {
+			t.Errorf("header order of profile %v = %v, want order %v", p.Name, got, p.HeaderOrder)
+		}
+	}
+}
+
+func TestNew_ProfileHTTP2(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.NotFoundHandler())
+	server.StartTLS()
+	defer server.Close()
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: server.TLS.Certificates, NextProtos: []string{"h2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	prefaces := make(chan *http2Preface, 1)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			prefaces <- readHTTP2Preface(conn)
+			conn.Close()
+		}
+	}()
+
+	for _, p := range Profiles() {
+		c := NewWithOptions(WithInsecureSkipVerify(true), WithProfile(p))
+		if _, err := c.R().Get("https://" + ln.Addr().String()); err != nil {
+			t.Fatalf("Get() with profile %v error = %v", p.Name, err)
+		}
+		got := <-prefaces
+		if got.err != nil {
+			t.Fatalf("profile %v http2 preface error = %v", p.Name, got.err)
+		}
+		want := p.HTTP2
+		if !reflect.DeepEqual(got.settings, want.Settings) {
+			t.Errorf("profile %v settings = %v, want %v", p.Name, got.settings, want.Settings)
+		}
+		if got.flow != want.ConnectionFlow {
+			t.Errorf("profile %v connection flow = %v, want %v", p.Name, got.flow, want.ConnectionFlow)
+		}
+		if len(got.priorities) != len(want.PriorityFrames) {
+			t.Errorf("profile %v priority frames = %v, want %v", p.Name, got.priorities, want.PriorityFrames)
+		}
+		if got.priority.Weight != want.HeaderPriority.Weight || got.priority.StreamDep != want.HeaderPriority.StreamDep || got.priority.Exclusive != want.HeaderPriority.Exclusive {
+			t.Errorf("profile %v header priority = %+v, want %+v", p.Name, got.priority, want.HeaderPriority)
+		}
+		if !reflect.DeepEqual(got.pseudo, want.PseudoHeaderOrder) {
+			t.Errorf("profile %v pseudo header order = %v, want %v", p.Name, got.pseudo, want.PseudoHeaderOrder)
+		}
+		if !inOrder(got.headers, p.HeaderOrder, len(p.Headers)+1) {
+			t.Errorf("profile %v header order = %v, want order %v", p.Name, got.headers, p.HeaderOrder)
+		}
+	}
+}
+
+// http2Preface is client connection preface and first request
+type http2Preface struct {
+	settings   []http2.Setting
+	flow       uint32
+	priorities []http2.PriorityFrame
+	priority   xhttp2.PriorityParam
+	pseudo     []string
+	headers    []string
+	err        error
+}
+
+// readHTTP2Preface reads client frames until first request headers and answers it
+func readHTTP2Preface(conn net.Conn) *http2Preface {
+	p := &http2Preface{}
+	preface := make([]byte, len(xhttp2.ClientPreface))
+	if _, p.err = io.ReadFull(conn, preface); p.err != nil {
+		return p
+	}
+	framer := xhttp2.NewFramer(conn, conn)
+	framer.ReadMetaHeaders = hpack.NewDecoder(65536, nil)
+	for {
+		frame, err := framer.ReadFrame()
+		if err != nil {
+			p.err = err
+			return p
+		}
+		switch f := frame.(type) {
+		case *xhttp2.SettingsFrame:
+			if f.IsAck() {
+				continue
+			}
+			_ = f.ForeachSetting(func(s xhttp2.Setting) error {
+				p.settings = append(p.settings, http2.Setting{ID: http2.SettingID(s.ID), Val: s.Val})
+				return nil
+			})
+		case *xhttp2.WindowUpdateFrame:
+			if f.StreamID == 0 {
+				p.flow = f.Increment
+			}
+		case *xhttp2.PriorityFrame:
+			p.priorities = append(p.priorities, http2.PriorityFrame{StreamID: f.StreamID})
+		case *xhttp2.MetaHeadersFrame:
+			p.priority = f.Priority
+			for _, field := range f.Fields {
+				if field.IsPseudo() {
+					p.pseudo = append(p.pseudo, field.Name)
+				} else {
+					p.headers = append(p.headers, field.Name)
+				}
+			}
+
+			var buf bytes.Buffer
+			_ = hpack.NewEncoder(&buf).WriteField(hpack.HeaderField{Name: ":status", Value: "200"})
+			_ = framer.WriteSettings()
+			_ = framer.WriteSettingsAck()
+			p.err = framer.WriteHeaders(xhttp2.HeadersFrameParam{StreamID: f.StreamID, BlockFragment: buf.Bytes(), EndStream: true, EndHeaders: true})
+			return p
+		}
+	}
+}
+
+// inOrder reports whether got has at least min names of order and keeps their order
+func inOrder(got, order []string, min int) bool {
+	index := make(map[string]int, len(order))
+	for i, name := range order {
+		index[name] = i
+	}
+	last, found := -1, 0
+	for _, name := range got {
+		i, ok := index[name]
+		if !ok {
+			continue
+		}
+		if i < last {
+			return false
+		}
+		last = i
+		found++
+	}
+	return found >= min
+}
@@ -0,0 +1,340 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/imroc/req/v3"
+)
+
+// shared limiter of all clients
+var (
+	sharedLimiter       *RateLimiter
+	sharedLimiterLocker sync.RWMutex
+)
+
+// HostLimit is a rate and concurrency limit of hosts matching pattern,
+// pattern is a host, a path.Match pattern like *.example.com or * for all hosts
+type HostLimit struct {
+	Pattern     string  `json:"pattern"`
+	Rate        float64 `json:"rate"`          // requests per second, zero is unlimited
+	Burst       int     `json:"burst"`         // bucket size, at least 1
+	MaxInFlight int     `json:"max_in_flight"` // zero is unlimited
+	Shared      bool    `json:"shared"`        // all matching hosts share one limit
+}
+
+// HostLimitStats struct
+type HostLimitStats struct {
+	Key      string `json:"key"`
+	Pattern  string `json:"pattern"`
+	Waiting  int    `json:"waiting"`
+	InFlight int    `json:"in_flight"`
+}
+
+// RateLimiter limits requests per host by token bucket and in flight count
+type RateLimiter struct {
+	limits   []HostLimit
+	limiters map[string]*hostLimiter
+	locker   sync.RWMutex
+}
+
+// hostLimiter is the limit state of one host or pattern
+type hostLimiter struct {
+	limit    HostLimit
+	tokens   float64
+	last     time.Time
+	slots    chan struct{}
+	waiting  int
+	inFlight int
+	locker   sync.Mutex
+}
+
+// NewRateLimiter returns new rate limiter
+func NewRateLimiter(limits ...HostLimit) *RateLimiter {
+	l := &RateLimiter{
+		limiters: make(map[string]*hostLimiter),
+		locker:   sync.RWMutex{},
+	}
+	for _, limit := range limits {
+		l.SetLimit(limit)
+	}
+
+	return l
+}
+
+// SetSharedRateLimiter sets limiter used by every client which has no own limiter, nil disables it
+func SetSharedRateLimiter(l *RateLimiter) {
+	sharedLimiterLocker.Lock()
+	sharedLimiter = l
+	sharedLimiterLocker.Unlock()
+}
+
+// SharedRateLimiter returns shared limiter
+func SharedRateLimiter() *RateLimiter {
+	sharedLimiterLocker.RLock()
+	defer sharedLimiterLocker.RUnlock()
+
+	return sharedLimiter
+}
+
+// WithRateLimiter sets client rate limiter
+func WithRateLimiter(l *RateLimiter) Option {
+	return func(o *Options) { o.RateLimiter = l }
+}
+
+// SetLimit adds or replaces limit of pattern, exact hosts are matched before patterns
+func (l *RateLimiter) SetLimit(limit HostLimit) {
+	limit.Pattern = strings.ToLower(limit.Pattern)
+
+	l.locker.Lock()
+	defer l.locker.Unlock()
+
+	replaced := false
+	for i, item := range l.limits {
+		if item.Pattern == limit.Pattern {
+			l.limits[i] = limit
+			replaced = true
+		}
+	}
+	if !replaced {
+		l.limits = append(l.limits, limit)
+	}
+
+	// reset state of changed pattern
+	for key, hl := range l.limiters {
+		if hl.limit.Pattern == limit.Pattern {
+			delete(l.limiters, key)
+		}
+	}
+}
+
+// RemoveLimit removes limit of pattern
+func (l *RateLimiter) RemoveLimit(pattern string) {
+	pattern = strings.ToLower(pattern)
+
+	l.locker.Lock()
+	defer l.locker.Unlock()
+
+	for i, item := range l.limits {
+		if item.Pattern == pattern {
+			l.limits = append(l.limits[:i], l.limits[i+1:]...)
+			break
+		}
+	}
+	for key, hl := range l.limiters {
+		if hl.limit.Pattern == pattern {
+			delete(l.limiters, key)
+		}
+	}
+}
+
+// Wait blocks until host request is allowed or context is done,
+// release must be called when request is finished
+func (l *RateLimiter) Wait(ctx context.Context, host string) (release func(), err error) {
+	hl := l.limiter(strings.ToLower(host))
+	if hl == nil {
+		return func() {}, nil
+	}
+
+	hl.locker.Lock()
+	hl.waiting++
+	hl.locker.Unlock()
+	defer func() {
+		hl.locker.Lock()
+		hl.waiting--
+		hl.locker.Unlock()
+	}()
+
+	if err = hl.take(ctx); err != nil {
+		return nil, err
+	}
+	if hl.slots == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case hl.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	hl.locker.Lock()
+	hl.inFlight++
+	hl.locker.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			hl.locker.Lock()
+			hl.inFlight--
+			hl.locker.Unlock()
+			<-hl.slots
+		})
+	}, nil
+}
+
+// QueueDepth returns count of requests waiting for host
+func (l *RateLimiter) QueueDepth(host string) int {
+	hl := l.limiter(strings.ToLower(host))
+	if hl == nil {
+		return 0
+	}
+
+	hl.locker.Lock()
+	defer hl.locker.Unlock()
+
+	return hl.waiting
+}
+
+// Stats returns limit stats of hosts
+func (l *RateLimiter) Stats() []HostLimitStats {
+	l.locker.RLock()
+	defer l.locker.RUnlock()
+
+	stats := make([]HostLimitStats, 0, len(l.limiters))
+	for key, hl := range l.limiters {
+		hl.locker.Lock()
+		stats = append(stats, HostLimitStats{
+			Key:      key,
+			Pattern:  hl.limit.Pattern,
+			Waiting:  hl.waiting,
+			InFlight: hl.inFlight,
+		})
+		hl.locker.Unlock()
+	}
+
+	return stats
+}
+
+// Attach limits client requests, redirects are limited as well
+func (l *RateLimiter) Attach(c *req.Client) {
+	c.GetTransport().WrapRoundTripFunc(func(rt http.RoundTripper) req.HttpRoundTripFunc {
+		return func(r *http.Request) (*http.Response, error) {
+			release, err := l.Wait(r.Context(), r.URL.Hostname())
+			if err != nil {
+				return nil, err
+			}
+
+			resp, err := rt.RoundTrip(r)
+			if err != nil || resp.Body == nil {
+				release()
+				return resp, err
+			}
+
+			// keep in flight slot until body is closed
+			resp.Body = &releaseBody{ReadCloser: resp.Body, release: release}
+			return resp, nil
+		}
+	})
+}
+
+// limiter returns limiter of host, nil when host has no limit
+func (l *RateLimiter) limiter(host string) *hostLimiter {
+	l.locker.RLock()
+	limit, ok := l.match(host)
+	if !ok {
+		l.locker.RUnlock()
+		return nil
+	}
+	key := host
+	if limit.Shared {
+		key = limit.Pattern
+	}
+	hl, ok := l.limiters[key]
+	l.locker.RUnlock()
+	if ok {
+		return hl
+	}
+
+	l.locker.Lock()
+	defer l.locker.Unlock()
+	if hl, ok = l.limiters[key]; !ok {
+		hl = newHostLimiter(limit)
+		l.limiters[key] = hl
+	}
+	return hl
+}
+
+// match returns limit of host, caller must hold the lock
+func (l *RateLimiter) match(host string) (HostLimit, bool) {
+	for _, limit := range l.limits {
+		if limit.Pattern == host {
+			return limit, true
+		}
+	}
+	for _, limit := range l.limits {
+		if ok, _ := path.Match(limit.Pattern, host); ok {
+			return limit, true
+		}
+	}
+	return HostLimit{}, false
+}
+
+// newHostLimiter returns new host limiter
+func newHostLimiter(limit HostLimit) *hostLimiter {
+	if limit.Burst < 1 {
+		limit.Burst = 1
+	}
+
+	hl := &hostLimiter{
+		limit:  limit,
+		tokens: float64(limit.Burst),
+		last:   time.Now(),
+	}
+	if limit.MaxInFlight > 0 {
+		hl.slots = make(chan struct{}, limit.MaxInFlight)
+	}
+	return hl
+}
+
+// take takes a token from bucket and waits for it if needed
+func (hl *hostLimiter) take(ctx context.Context) error {
+	if hl.limit.Rate <= 0 {
+		return nil
+	}
+
+	hl.locker.Lock()
+	now := time.Now()
+	hl.tokens += now.Sub(hl.last).Seconds() * hl.limit.Rate
+	if burst := float64(hl.limit.Burst); hl.tokens > burst {
+		hl.tokens = burst
+	}
+	hl.last = now
+	hl.tokens--
+	wait := time.Duration(-hl.tokens / hl.limit.Rate * float64(time.Second))
+	hl.locker.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// give back reserved token
+		hl.locker.Lock()
+		hl.tokens++
+		hl.locker.Unlock()
+		return ctx.Err()
+	}
+}
+
+// releaseBody releases limiter slot on close
+type releaseBody struct {
+	io.ReadCloser
+	release func()
+}
+
+// Close closes body and releases slot
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
@@ -0,0 +1,65 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	var inFlight, maxInFlight int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			m := atomic.LoadInt32(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+				break
+			}
+		}
+		<-release
+	}))
+	defer server.Close()
+
+	limiter := NewRateLimiter(HostLimit{Pattern: "127.0.0.*", Rate: 100, Burst: 2, MaxInFlight: 2})
+	c := NewWithOptions(WithRateLimiter(limiter))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.R().Get(server.URL); err != nil {
+				t.Errorf("Get() error = %v", err)
+			}
+		}()
+	}
+	// requests wait while handlers are blocked
+	deadline := time.Now().Add(time.Second * 5)
+	for limiter.QueueDepth("127.0.0.1") == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if depth := limiter.QueueDepth("127.0.0.1"); depth == 0 {
+		t.Errorf("QueueDepth() = 0, want waiting requests")
+	}
+	close(release)
+	wg.Wait()
+
+	if maxInFlight > 2 {
+		t.Errorf("max in flight = %v, want <= 2", maxInFlight)
+	}
+
+	// blocked request respects context
+	blocking := NewRateLimiter(HostLimit{Pattern: "*", Rate: 0.001})
+	_, _ = blocking.Wait(context.Background(), "example.com")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*20)
+	defer cancel()
+	if _, err := blocking.Wait(ctx, "example.com"); err == nil {
+		t.Errorf("Wait() error = nil, want context error")
+	}
+}
@@ -1,21 +1,243 @@
 package client
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/imroc/req/v3"
 )
 
+// ErrRedirectDenied is returned when a redirect policy denies a redirect
+var ErrRedirectDenied = errors.New("redirect denied")
+
+// redirectResultKey is context key of redirect result
+type redirectResultKey struct{}
+
+// RedirectHop is a followed redirect
+type RedirectHop struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Status int    `json:"status"`
+}
+
+// RedirectResult keeps captured values and redirect chain of one request
+type RedirectResult struct {
+	chain  []RedirectHop
+	values map[string]string
+	locker sync.RWMutex
+}
+
+// NewRedirectContext returns context which collects redirect result of request,
+// capture and record policies write into it so they are safe between concurrent requests
+func NewRedirectContext(ctx context.Context) (context.Context, *RedirectResult) {
+	result := &RedirectResult{values: make(map[string]string), locker: sync.RWMutex{}}
+	return context.WithValue(ctx, redirectResultKey{}, result), result
+}
+
+// RedirectResultFrom returns redirect result of context
+func RedirectResultFrom(ctx context.Context) (*RedirectResult, bool) {
+	result, ok := ctx.Value(redirectResultKey{}).(*RedirectResult)
+	return result, ok
+}
+
+// Chain returns recorded redirect chain
+func (r *RedirectResult) Chain() []RedirectHop {
+	r.locker.RLock()
+	defer r.locker.RUnlock()
+
+	return append([]RedirectHop{}, r.chain...)
+}
+
+// Value returns captured value
+func (r *RedirectResult) Value(name string) (string, bool) {
+	r.locker.RLock()
+	defer r.locker.RUnlock()
+
+	v, ok := r.values[name]
+	return v, ok
+}
+
+// Values returns all captured values
+func (r *RedirectResult) Values() map[string]string {
+	r.locker.RLock()
+	defer r.locker.RUnlock()
+
+	values := make(map[string]string, len(r.values))
+	for k, v := range r.values {
+		values[k] = v
+	}
+	return values
+}
+
+// set sets captured value once
+func (r *RedirectResult) set(name, value string) {
+	r.locker.Lock()
+	if _, ok := r.values[name]; !ok {
+		r.values[name] = value
+	}
+	r.locker.Unlock()
+}
+
 // DontFollow is a redirect policy that does not follow redirects
 func DontFollow(req *http.Request, via []*http.Request) error {
 	return http.ErrUseLastResponse
 }
 
+// ComposeRedirect returns policy which runs policies in order until one stops
+func ComposeRedirect(policies ...req.RedirectPolicy) req.RedirectPolicy {
+	return func(req *http.Request, via []*http.Request) error {
+		for _, policy := range policies {
+			if err := policy(req, via); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// MaxHops denies more than n redirects
+func MaxHops(n int) req.RedirectPolicy {
+	return func(req *http.Request, via []*http.Request) error {
+		if len(via) > n {
+			return fmt.Errorf("%w: stopped after %d redirects", ErrRedirectDenied, n)
+		}
+		return nil
+	}
+}
+
+// SameHostOnly denies redirects to another host
+func SameHostOnly() req.RedirectPolicy {
+	return func(req *http.Request, via []*http.Request) error {
+		if len(via) > 0 && !strings.EqualFold(req.URL.Host, via[0].URL.Host) {
+			return fmt.Errorf("%w: host %v is not %v", ErrRedirectDenied, req.URL.Host, via[0].URL.Host)
+		}
+		return nil
+	}
+}
+
+// AllowDomains denies redirects to hosts other than domains and their subdomains
+func AllowDomains(domains ...string) req.RedirectPolicy {
+	return func(req *http.Request, via []*http.Request) error {
+		if !matchDomains(req.URL.Hostname(), domains) {
+			return fmt.Errorf("%w: domain %v is not allowed", ErrRedirectDenied, req.URL.Hostname())
+		}
+		return nil
+	}
+}
+
+// DenyDomains denies redirects to domains and their subdomains
+func DenyDomains(domains ...string) req.RedirectPolicy {
+	return func(req *http.Request, via []*http.Request) error {
+		if matchDomains(req.URL.Hostname(), domains) {
+			return fmt.Errorf("%w: domain %v is denied", ErrRedirectDenied, req.URL.Hostname())
+		}
+		return nil
+	}
+}
+
+// StopOnMatch stops following and returns the redirect response when url matches pattern
+func StopOnMatch(pattern *regexp.Regexp) req.RedirectPolicy {
+	return func(req *http.Request, via []*http.Request) error {
+		if pattern.MatchString(req.URL.String()) {
+			return http.ErrUseLastResponse
+		}
+		return nil
+	}
+}
+
+// RecordChain records redirect chain with status codes into request redirect result
+func RecordChain() req.RedirectPolicy {
+	return func(req *http.Request, via []*http.Request) error {
+		result, ok := RedirectResultFrom(req.Context())
+		if !ok || len(via) == 0 {
+			return nil
+		}
+
+		hop := RedirectHop{From: via[len(via)-1].URL.String(), To: req.URL.String()}
+		if req.Response != nil {
+			hop.Status = req.Response.StatusCode
+		}
+		result.locker.Lock()
+		result.chain = append(result.chain, hop)
+		result.locker.Unlock()
+		return nil
+	}
+}
+
+// CaptureParam captures query param into request redirect result,
+// with stop it returns the redirect response once captured
+func CaptureParam(name string, stop bool) req.RedirectPolicy {
+	return capture(name, stop, func(u *url.URL) string {
+		return u.Query().Get(name)
+	})
+}
+
+// CaptureFragment captures fragment param (e.g. #access_token=x) into request redirect result,
+// empty name captures the whole fragment as "#"
+func CaptureFragment(name string, stop bool) req.RedirectPolicy {
+	key := name
+	if key == "" {
+		key = "#"
+	}
+	return capture(key, stop, func(u *url.URL) string {
+		if name == "" {
+			return u.Fragment
+		}
+		values, _ := url.ParseQuery(u.Fragment)
+		return values.Get(name)
+	})
+}
+
+// capture stores extracted value into request redirect result
+func capture(key string, stop bool, extract func(*url.URL) string) req.RedirectPolicy {
+	return func(req *http.Request, via []*http.Request) error {
+		value := extract(req.URL)
+		if value == "" {
+			return nil
+		}
+		if result, ok := RedirectResultFrom(req.Context()); ok {
+			result.set(key, value)
+		}
+		if stop {
+			return http.ErrUseLastResponse
+		}
+		return nil
+	}
+}
+
+// matchDomains reports whether host is one of domains or their subdomains
+func matchDomains(host string, domains []string) bool {
+	host = strings.ToLower(host)
+	for _, domain := range domains {
+		domain = strings.TrimPrefix(strings.ToLower(domain), ".")
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
 type InterceptResult = func() (string, error)
 
-// InterceptRedirect captures a redirect url parameter
+// InterceptRedirect captures a redirect url parameter, the value is reset on every new redirect chain.
+//
+// Deprecated: it is not safe between concurrent requests, use CaptureParam with NewRedirectContext
 func InterceptRedirect(param string, stop bool) (func(req *http.Request, via []*http.Request) error, InterceptResult) {
 	var val string
+	var locker sync.Mutex
 	return func(req *http.Request, via []*http.Request) error {
+			locker.Lock()
+			defer locker.Unlock()
+
+			if len(via) == 1 {
+				val = ""
+			}
 			if val == "" {
 				if val = req.URL.Query().Get(param); val != "" && stop {
 					return http.ErrUseLastResponse
@@ -24,6 +246,9 @@ func InterceptRedirect(param string, stop bool) (func(req *http.Request, via []*
 			return nil
 		},
 		func() (string, error) {
+			locker.Lock()
+			defer locker.Unlock()
+
 			var err error
 			if val == "" {
 				err = fmt.Errorf("%s not found", param)
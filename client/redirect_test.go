@@ -0,0 +1,61 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestRedirectPolicies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			http.Redirect(w, r, "/callback?code="+r.URL.Query().Get("user"), http.StatusFound)
+		case "/callback":
+			http.Redirect(w, r, "/done#access_token="+r.URL.Query().Get("code"), http.StatusMovedPermanently)
+		case "/away":
+			http.Redirect(w, r, "https://example.com/", http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	c := NewWithOptions(WithRedirectPolicy(MaxHops(5), SameHostOnly(), RecordChain(), CaptureParam("code", false), CaptureFragment("access_token", true)))
+
+	// concurrent requests keep their own results
+	var wg sync.WaitGroup
+	for _, user := range []string{"a", "b", "c", "d"} {
+		wg.Add(1)
+		go func(user string) {
+			defer wg.Done()
+
+			ctx, result := NewRedirectContext(context.Background())
+			resp, err := c.R().SetContext(ctx).Get(server.URL + "/login?user=" + user)
+			if err != nil {
+				t.Errorf("Get() error = %v", err)
+				return
+			}
+			if resp.StatusCode != http.StatusMovedPermanently {
+				t.Errorf("Get() status = %v, want %v", resp.StatusCode, http.StatusMovedPermanently)
+			}
+			if code, _ := result.Value("code"); code != user {
+				t.Errorf("Value(code) = %v, want %v", code, user)
+			}
+			if token, _ := result.Value("access_token"); token != user {
+				t.Errorf("Value(access_token) = %v, want %v", token, user)
+			}
+			if chain := result.Chain(); len(chain) != 2 || chain[0].Status != http.StatusFound || chain[1].Status != http.StatusMovedPermanently {
+				t.Errorf("Chain() = %v", chain)
+			}
+		}(user)
+	}
+	wg.Wait()
+
+	if _, err := c.R().Get(server.URL + "/away"); !errors.Is(err, ErrRedirectDenied) {
+		t.Errorf("Get() error = %v, want %v", err, ErrRedirectDenied)
+	}
+}
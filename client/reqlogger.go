@@ -1,17 +1,353 @@
 package client
 
-import "fmt"
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
 
-type RequestLogger struct{}
+	"github.com/go-per/simpkg/logger"
+	"github.com/go-per/simpkg/std"
+	"github.com/imroc/req/v3"
+)
 
+// Redacted replaces redacted values
+const Redacted = "[REDACTED]"
+
+// default redaction lists
+var (
+	defaultRedactHeaders = []string{"Authorization", "Proxy-Authorization", "X-Ui-Token"}
+	defaultRedactFields  = []string{"password", "pass", "token", "access_token", "refresh_token", "secret", "api_key"}
+)
+
+// RequestLogger sends req logs and request dumps to logger.ILogger,
+// without logger it writes to std which honours std.SetIsDebug
+type RequestLogger struct {
+	logger        logger.ILogger
+	dump          bool
+	maxBodySize   int
+	redactHeaders map[string]bool
+	redactCookies map[string]bool
+	redactFields  map[string]bool
+	locker        sync.RWMutex
+}
+
+// RequestDump is a logged request summary
+type RequestDump struct {
+	Method          string            `json:"method"`
+	URL             string            `json:"url"`
+	Status          int               `json:"status,omitempty"`
+	Latency         string            `json:"latency"`
+	RequestSize     int               `json:"request_size"`
+	ResponseSize    int               `json:"response_size"`
+	RequestHeaders  map[string]string `json:"request_headers,omitempty"`
+	ResponseHeaders map[string]string `json:"response_headers,omitempty"`
+	RequestBody     string            `json:"request_body,omitempty"`
+	ResponseBody    string            `json:"response_body,omitempty"`
+	Error           string            `json:"error,omitempty"`
+}
+
+// NewRequestLogger returns new request logger
+func NewRequestLogger(l ...logger.ILogger) *RequestLogger {
+	rl := &RequestLogger{
+		maxBodySize: 2048,
+		locker:      sync.RWMutex{},
+	}
+	if len(l) > 0 {
+		rl.logger = l[0]
+	}
+	rl.SetRedactHeaders(defaultRedactHeaders...)
+	rl.SetRedactCookies("*")
+	rl.SetRedactFields(defaultRedactFields...)
+
+	return rl
+}
+
+// WithRequestLogger sets request logger and registers request dumps
+func WithRequestLogger(l *RequestLogger) Option {
+	return func(o *Options) {
+		o.Logger = l
+		o.Configure = append(o.Configure, l.Attach)
+	}
+}
+
+// SetLogger sets target logger
+func (l *RequestLogger) SetLogger(target logger.ILogger) *RequestLogger {
+	l.locker.Lock()
+	l.logger = target
+	l.locker.Unlock()
+	return l
+}
+
+// EnableDump enables or disables per request dumps
+func (l *RequestLogger) EnableDump(v bool) *RequestLogger {
+	l.locker.Lock()
+	l.dump = v
+	l.locker.Unlock()
+	return l
+}
+
+// SetMaxBodySize sets max dumped body size, zero omits bodies
+func (l *RequestLogger) SetMaxBodySize(size int) *RequestLogger {
+	l.locker.Lock()
+	l.maxBodySize = size
+	l.locker.Unlock()
+	return l
+}
+
+// SetRedactHeaders sets redacted header names
+func (l *RequestLogger) SetRedactHeaders(names ...string) *RequestLogger {
+	l.locker.Lock()
+	l.redactHeaders = toLowerSet(names)
+	l.locker.Unlock()
+	return l
+}
+
+// SetRedactCookies sets redacted cookie names, "*" redacts all cookies
+func (l *RequestLogger) SetRedactCookies(names ...string) *RequestLogger {
+	l.locker.Lock()
+	l.redactCookies = toLowerSet(names)
+	l.locker.Unlock()
+	return l
+}
+
+// SetRedactFields sets redacted json body, form and query fields
+func (l *RequestLogger) SetRedactFields(fields ...string) *RequestLogger {
+	l.locker.Lock()
+	l.redactFields = toLowerSet(fields)
+	l.locker.Unlock()
+	return l
+}
+
+// Errorf implements req.Logger
 func (l *RequestLogger) Errorf(format string, v ...any) {
-	fmt.Println(fmt.Sprintf("[ERROR] "+format, v...))
+	l.write(logger.MessageError, fmt.Sprintf(format, v...), nil)
 }
 
+// Warnf implements req.Logger
 func (l *RequestLogger) Warnf(format string, v ...any) {
-	fmt.Println(fmt.Sprintf("[WARNING] "+format, v...))
+	l.write(logger.MessageInfo, fmt.Sprintf("[WARNING] "+format, v...), nil)
 }
 
+// Debugf implements req.Logger
 func (l *RequestLogger) Debugf(format string, v ...any) {
-	fmt.Println(fmt.Sprintf("[DEBUG] "+format, v...))
+	l.write(logger.MessageDebug, fmt.Sprintf(format, v...), nil)
+}
+
+// Attach registers request dump on client
+func (l *RequestLogger) Attach(c *req.Client) {
+	c.OnAfterResponse(func(_ *req.Client, resp *req.Response) error {
+		l.locker.RLock()
+		dump := l.dump
+		l.locker.RUnlock()
+		if !dump && resp.Err == nil {
+			return nil
+		}
+
+		d := l.Dump(resp)
+		if d.Error != "" {
+			l.write(logger.MessageError, "request failed "+d.Method+" "+d.URL, d)
+		} else {
+			l.write(logger.MessageDebug, "request "+d.Method+" "+d.URL, d)
+		}
+		return nil
+	})
+}
+
+// Dump returns redacted dump of response
+func (l *RequestLogger) Dump(resp *req.Response) RequestDump {
+	l.locker.RLock()
+	defer l.locker.RUnlock()
+
+	r := resp.Request
+	d := RequestDump{
+		Method:      r.Method,
+		Latency:     resp.TotalTime().Round(time.Millisecond).String(),
+		RequestSize: len(r.Body),
+	}
+	if r.RawRequest != nil {
+		d.URL = l.redactURL(r.RawRequest.URL)
+		d.RequestHeaders = l.redactHeaderMap(r.RawRequest.Header)
+	} else {
+		d.URL = r.RawURL
+	}
+	if resp.Err != nil {
+		d.Error = resp.Err.Error()
+	}
+	if resp.Response != nil {
+		d.Status = resp.StatusCode
+		d.ResponseHeaders = l.redactHeaderMap(resp.Header)
+		d.ResponseSize = len(resp.Bytes())
+	}
+	if l.maxBodySize > 0 {
+		d.RequestBody = l.redactBody(r.Body)
+		d.ResponseBody = l.redactBody(resp.Bytes())
+	}
+
+	return d
+}
+
+// write writes message to logger or std, debug messages only in debug mode
+func (l *RequestLogger) write(t logger.MessageType, message string, data any) {
+	if t == logger.MessageDebug && !std.IsDebug() {
+		return
+	}
+
+	l.locker.RLock()
+	target := l.logger
+	l.locker.RUnlock()
+
+	if target == nil {
+		if data != nil {
+			if b, err := json.Marshal(data); err == nil {
+				message += " " + string(b)
+			}
+		}
+		switch t {
+		case logger.MessageError:
+			std.Error("%s", message)
+		case logger.MessageDebug:
+			std.Debug("%s", message)
+		default:
+			std.Info("%s", message)
+		}
+		return
+	}
+
+	switch t {
+	case logger.MessageError:
+		target.Error(message, data)
+	case logger.MessageDebug:
+		target.Debug(message, data)
+	default:
+		target.Info(message, data)
+	}
+}
+
+// redactURL returns url with redacted query fields
+func (l *RequestLogger) redactURL(u *url.URL) string {
+	cp := *u
+	cp.User = nil
+	if query := cp.Query(); len(query) > 0 && l.redactValues(query) {
+		cp.RawQuery = query.Encode()
+	}
+	return cp.String()
+}
+
+// redactHeaderMap returns flat header map with redacted values
+func (l *RequestLogger) redactHeaderMap(header http.Header) map[string]string {
+	if len(header) == 0 {
+		return nil
+	}
+
+	m := make(map[string]string, len(header))
+	for name, values := range header {
+		value := strings.Join(values, ", ")
+		switch lower := strings.ToLower(name); {
+		case l.redactHeaders[lower]:
+			value = Redacted
+		case lower == "cookie":
+			value = l.redactCookieHeader(values, false)
+		case lower == "set-cookie":
+			value = l.redactCookieHeader(values, true)
+		}
+		m[name] = value
+	}
+
+	return m
+}
+
+// redactCookieHeader redacts cookie values by name
+func (l *RequestLogger) redactCookieHeader(values []string, isSet bool) string {
+	if l.redactCookies["*"] {
+		return Redacted
+	}
+
+	var cookies []*http.Cookie
+	if isSet {
+		cookies = (&http.Response{Header: http.Header{"Set-Cookie": values}}).Cookies()
+	} else {
+		cookies = (&http.Request{Header: http.Header{"Cookie": values}}).Cookies()
+	}
+
+	parts := make([]string, 0, len(cookies))
+	for _, c := range cookies {
+		value := c.Value
+		if l.redactCookies[strings.ToLower(c.Name)] {
+			value = Redacted
+		}
+		parts = append(parts, c.Name+"="+value)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// redactBody redacts json or form body fields and truncates it
+func (l *RequestLogger) redactBody(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	text := string(body)
+	var v any
+	if err := json.Unmarshal(body, &v); err == nil {
+		if l.redactJSON(v) {
+			if b, err := json.Marshal(v); err == nil {
+				text = string(b)
+			}
+		}
+	} else if form, err := url.ParseQuery(text); err == nil && strings.Contains(text, "=") {
+		if l.redactValues(form) {
+			text = form.Encode()
+		}
+	}
+
+	if len(text) > l.maxBodySize {
+		text = fmt.Sprintf("%s...(truncated %d bytes)", text[:l.maxBodySize], len(text)-l.maxBodySize)
+	}
+	return text
+}
+
+// redactJSON redacts json fields in place, reports whether anything is redacted
+func (l *RequestLogger) redactJSON(v any) (changed bool) {
+	switch val := v.(type) {
+	case map[string]any:
+		for key, item := range val {
+			if l.redactFields[strings.ToLower(key)] {
+				val[key] = Redacted
+				changed = true
+			} else if l.redactJSON(item) {
+				changed = true
+			}
+		}
+	case []any:
+		for _, item := range val {
+			if l.redactJSON(item) {
+				changed = true
+			}
+		}
+	}
+	return
+}
+
+// redactValues redacts url values in place, reports whether anything is redacted
+func (l *RequestLogger) redactValues(values url.Values) (changed bool) {
+	for key := range values {
+		if l.redactFields[strings.ToLower(key)] {
+			values[key] = []string{Redacted}
+			changed = true
+		}
+	}
+	return
+}
+
+// toLowerSet returns lower case set of names
+func toLowerSet(names []string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[strings.ToLower(name)] = true
+	}
+	return set
 }
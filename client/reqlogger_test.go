@@ -0,0 +1,37 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestLogger_Dump(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "sid", Value: "secret-sid"})
+		_, _ = w.Write([]byte(`{"access_token":"secret-token","items":[{"password":"secret-pass"}],"name":"` + strings.Repeat("a", 100) + `"}`))
+	}))
+	defer server.Close()
+
+	l := NewRequestLogger().SetMaxBodySize(80)
+	resp, err := NewWithOptions(WithRequestLogger(l)).R().
+		SetHeader("Authorization", "Bearer secret-auth").
+		SetBody(map[string]string{"user": "u", "password": "secret-pass"}).
+		Post(server.URL + "?token=secret-query&page=1")
+	if err != nil {
+		t.Fatalf("Post() error = %v", err)
+	}
+
+	d := l.Dump(resp)
+	if d.Status != http.StatusOK || d.Method != http.MethodPost {
+		t.Errorf("Dump() status = %v, method = %v", d.Status, d.Method)
+	}
+	if d.ResponseSize <= 80 || !strings.Contains(d.ResponseBody, "truncated") {
+		t.Errorf("Dump() response body is not truncated, %v", d.ResponseBody)
+	}
+	dump := d.URL + d.RequestBody + d.ResponseBody + d.RequestHeaders["Authorization"] + d.ResponseHeaders["Set-Cookie"]
+	if strings.Contains(dump, "secret") {
+		t.Errorf("Dump() is not redacted, %v", dump)
+	}
+}
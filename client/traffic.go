@@ -0,0 +1,300 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"sync/atomic"
+
+	"github.com/imroc/req/v3"
+)
+
+// TrafficScope type
+type TrafficScope string
+
+const (
+	TrafficTotal  TrafficScope = "total"
+	TrafficClient TrafficScope = "client"
+	TrafficHost   TrafficScope = "host"
+	TrafficProxy  TrafficScope = "proxy"
+)
+
+// DirectProxy is proxy key of requests without proxy
+const DirectProxy = "direct"
+
+// trafficKey is context key of request traffic attribution
+type trafficKey struct{}
+
+// TrafficStats struct
+type TrafficStats struct {
+	Requests int64 `json:"requests"`
+	Sent     int64 `json:"sent"`
+	Received int64 `json:"received"`
+}
+
+// Bytes returns sent and received bytes
+func (s TrafficStats) Bytes() int64 {
+	return s.Sent + s.Received
+}
+
+// ErrQuotaExceeded is returned without sending request when traffic budget is used
+type ErrQuotaExceeded struct {
+	Scope  TrafficScope
+	Key    string
+	Budget int64
+	Used   int64
+}
+
+// Error implements error
+func (e *ErrQuotaExceeded) Error() string {
+	return fmt.Sprintf("traffic quota exceeded for %v %v, used %d of %d bytes", e.Scope, e.Key, e.Used, e.Budget)
+}
+
+// TrafficMeter counts wire bytes, headers and tls included, per client, host and proxy
+type TrafficMeter struct {
+	counters map[TrafficScope]map[string]*trafficCounter
+	budgets  map[TrafficScope]map[string]int64
+	locker   sync.RWMutex
+}
+
+// trafficCounter struct
+type trafficCounter struct {
+	requests int64
+	sent     int64
+	received int64
+}
+
+// trafficTarget is the counters of one request
+type trafficTarget struct {
+	meter    *TrafficMeter
+	keys     map[TrafficScope]string
+	counters map[TrafficScope]*trafficCounter
+}
+
+// NewTrafficMeter returns new traffic meter
+func NewTrafficMeter() *TrafficMeter {
+	m := &TrafficMeter{locker: sync.RWMutex{}}
+	m.Reset()
+	m.budgets = make(map[TrafficScope]map[string]int64)
+
+	return m
+}
+
+// WithTrafficMeter counts client traffic, name is the client key, e.g. worker id
+func WithTrafficMeter(m *TrafficMeter, name string) Option {
+	return func(o *Options) {
+		o.Configure = append(o.Configure, func(c *req.Client) { m.Attach(c, name) })
+	}
+}
+
+// SetBudget sets max bytes of scope key, total scope has empty key, zero removes budget
+func (m *TrafficMeter) SetBudget(scope TrafficScope, key string, bytes int64) {
+	m.locker.Lock()
+	defer m.locker.Unlock()
+
+	if _, ok := m.budgets[scope]; !ok {
+		m.budgets[scope] = make(map[string]int64)
+	}
+	if bytes <= 0 {
+		delete(m.budgets[scope], key)
+		return
+	}
+	m.budgets[scope][key] = bytes
+}
+
+// Total returns total traffic
+func (m *TrafficMeter) Total() TrafficStats {
+	return m.Stats(TrafficTotal)[""]
+}
+
+// Stats returns traffic of scope keys
+func (m *TrafficMeter) Stats(scope TrafficScope) map[string]TrafficStats {
+	m.locker.RLock()
+	defer m.locker.RUnlock()
+
+	stats := make(map[string]TrafficStats, len(m.counters[scope]))
+	for key, c := range m.counters[scope] {
+		stats[key] = c.stats()
+	}
+	return stats
+}
+
+// Reset resets all counters, counters are zeroed in place as open connections keep them
+func (m *TrafficMeter) Reset() {
+	m.locker.Lock()
+	defer m.locker.Unlock()
+
+	if m.counters == nil {
+		m.counters = map[TrafficScope]map[string]*trafficCounter{
+			TrafficTotal:  {"": {}},
+			TrafficClient: {},
+			TrafficHost:   {},
+			TrafficProxy:  {},
+		}
+		return
+	}
+	for _, counters := range m.counters {
+		for _, c := range counters {
+			atomic.StoreInt64(&c.requests, 0)
+			atomic.StoreInt64(&c.sent, 0)
+			atomic.StoreInt64(&c.received, 0)
+		}
+	}
+}
+
+// Attach counts client traffic, dial is wrapped so bytes are counted on the connection and
+// every request moves the counters of its connection to itself, so reused connections are
+// counted per request. Budgets are checked before requests and while bytes are transferred
+func (m *TrafficMeter) Attach(c *req.Client, name string) {
+	t := c.GetTransport()
+
+	dial := t.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	t.SetDial(func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		if target, ok := ctx.Value(trafficKey{}).(*trafficTarget); ok && target.meter == m {
+			tc := &trafficConn{Conn: conn}
+			tc.target.Store(target)
+			return tc, nil
+		}
+		return conn, nil
+	})
+
+	t.WrapRoundTripFunc(func(rt http.RoundTripper) req.HttpRoundTripFunc {
+		return func(r *http.Request) (*http.Response, error) {
+			proxy := DirectProxy
+			if t.Proxy != nil {
+				if u, err := t.Proxy(r); err == nil && u != nil {
+					proxy = u.Scheme + "://" + u.Host
+				}
+			}
+
+			keys := map[TrafficScope]string{
+				TrafficTotal:  "",
+				TrafficClient: name,
+				TrafficHost:   r.URL.Hostname(),
+				TrafficProxy:  proxy,
+			}
+			target := m.target(keys)
+			if err := m.check(target); err != nil {
+				return nil, err
+			}
+			for _, counter := range target.counters {
+				atomic.AddInt64(&counter.requests, 1)
+			}
+
+			// reused connections count for the request which got them
+			ctx := context.WithValue(r.Context(), trafficKey{}, target)
+			ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+				GotConn: func(info httptrace.GotConnInfo) {
+					if conn := unwrapTrafficConn(info.Conn); conn != nil && conn.meter() == m {
+						conn.target.Store(target)
+					}
+				},
+			})
+			return rt.RoundTrip(r.WithContext(ctx))
+		}
+	})
+}
+
+// check returns ErrQuotaExceeded when a budget of target is used, bytes of requests in
+// flight are counted as they are transferred
+func (m *TrafficMeter) check(target *trafficTarget) error {
+	m.locker.RLock()
+	defer m.locker.RUnlock()
+
+	for scope, key := range target.keys {
+		budget, ok := m.budgets[scope][key]
+		if !ok {
+			continue
+		}
+		if used := target.counters[scope].stats().Bytes(); used >= budget {
+			return &ErrQuotaExceeded{Scope: scope, Key: key, Budget: budget, Used: used}
+		}
+	}
+	return nil
+}
+
+// target returns counters of keys
+func (m *TrafficMeter) target(keys map[TrafficScope]string) *trafficTarget {
+	m.locker.Lock()
+	defer m.locker.Unlock()
+
+	target := &trafficTarget{meter: m, keys: keys, counters: make(map[TrafficScope]*trafficCounter, len(keys))}
+	for scope, key := range keys {
+		c, ok := m.counters[scope][key]
+		if !ok {
+			c = &trafficCounter{}
+			m.counters[scope][key] = c
+		}
+		target.counters[scope] = c
+	}
+	return target
+}
+
+// stats returns counter stats
+func (c *trafficCounter) stats() TrafficStats {
+	return TrafficStats{
+		Requests: atomic.LoadInt64(&c.requests),
+		Sent:     atomic.LoadInt64(&c.sent),
+		Received: atomic.LoadInt64(&c.received),
+	}
+}
+
+// trafficConn counts connection bytes for the request which uses the connection
+type trafficConn struct {
+	net.Conn
+	target atomic.Value // *trafficTarget
+}
+
+// Read counts received bytes, reading fails when budget is used
+func (conn *trafficConn) Read(b []byte) (int, error) {
+	n, err := conn.Conn.Read(b)
+	return n, conn.count(n, err, func(c *trafficCounter) *int64 { return &c.received })
+}
+
+// Write counts sent bytes, writing fails when budget is used
+func (conn *trafficConn) Write(b []byte) (int, error) {
+	n, err := conn.Conn.Write(b)
+	return n, conn.count(n, err, func(c *trafficCounter) *int64 { return &c.sent })
+}
+
+// meter returns meter of connection
+func (conn *trafficConn) meter() *TrafficMeter {
+	return conn.target.Load().(*trafficTarget).meter
+}
+
+// count adds n bytes to counter field of target, err is returned as is
+func (conn *trafficConn) count(n int, err error, field func(c *trafficCounter) *int64) error {
+	target := conn.target.Load().(*trafficTarget)
+	for _, c := range target.counters {
+		atomic.AddInt64(field(c), int64(n))
+	}
+	if err != nil || n == 0 {
+		return err
+	}
+	return target.meter.check(target)
+}
+
+// unwrapTrafficConn returns traffic connection under tls connections
+func unwrapTrafficConn(conn net.Conn) *trafficConn {
+	for conn != nil {
+		if tc, ok := conn.(*trafficConn); ok {
+			return tc
+		}
+		inner, ok := conn.(interface{ NetConn() net.Conn })
+		if !ok {
+			return nil
+		}
+		conn = inner.NetConn()
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestTrafficMeter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(strings.Repeat("a", 1000)))
+	}))
+	defer server.Close()
+
+	meter := NewTrafficMeter()
+	c := NewWithOptions(WithTrafficMeter(meter, "worker-1"))
+	if _, err := c.R().SetBody(strings.Repeat("b", 500)).Post(server.URL); err != nil {
+		t.Fatalf("Post() error = %v", err)
+	}
+
+	total := meter.Total()
+	if total.Requests != 1 || total.Sent <= 500 || total.Received <= 1000 {
+		t.Errorf("Total() = %+v", total)
+	}
+	if got := meter.Stats(TrafficClient)["worker-1"]; got != total {
+		t.Errorf("Stats(client) = %+v, want %+v", got, total)
+	}
+	if got := meter.Stats(TrafficProxy)[DirectProxy]; got != total {
+		t.Errorf("Stats(proxy) = %+v, want %+v", got, total)
+	}
+	if got := meter.Stats(TrafficHost)["127.0.0.1"]; got != total {
+		t.Errorf("Stats(host) = %+v, want %+v", got, total)
+	}
+
+	meter.SetBudget(TrafficClient, "worker-1", 1000)
+	var quotaErr *ErrQuotaExceeded
+	if _, err := c.R().Get(server.URL); !errors.As(err, &quotaErr) {
+		t.Errorf("Get() error = %v, want ErrQuotaExceeded", err)
+	}
+
+	meter.Reset()
+	if _, err := c.R().Get(server.URL); err != nil {
+		t.Errorf("Get() after Reset() error = %v", err)
+	}
+}
+
+func TestTrafficMeter_ReusedConnection(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(strings.Repeat("a", 1000)))
+	}))
+	defer server.Close()
+
+	// proxy keeps one connection to client for all hosts
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.RequestURI = ""
+		resp, err := http.DefaultTransport.RoundTrip(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+		w.WriteHeader(resp.StatusCode)
+		_, _ = io.Copy(w, resp.Body)
+	}))
+	defer proxy.Close()
+
+	meter := NewTrafficMeter()
+	c := NewWithOptions(WithProxy(proxy.URL), WithTrafficMeter(meter, "worker-1"))
+	u, _ := url.Parse(server.URL)
+	for _, host := range []string{"127.0.0.1", "localhost"} {
+		if _, err := c.R().Get("http://" + host + ":" + u.Port()); err != nil {
+			t.Fatalf("Get(%v) error = %v", host, err)
+		}
+	}
+
+	hosts := meter.Stats(TrafficHost)
+	for _, host := range []string{"127.0.0.1", "localhost"} {
+		if got := hosts[host]; got.Requests != 1 || got.Received < 1000 || got.Received > 1500 {
+			t.Errorf("Stats(host)[%v] = %+v, want one request of about 1000 bytes", host, got)
+		}
+	}
+}
+
+func TestTrafficMeter_BudgetInFlight(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(strings.Repeat("a", 1<<20)))
+	}))
+	defer server.Close()
+
+	meter := NewTrafficMeter()
+	meter.SetBudget(TrafficHost, "127.0.0.1", 10000)
+	c := NewWithOptions(WithTrafficMeter(meter, "worker-1"))
+
+	var quotaErr *ErrQuotaExceeded
+	if _, err := c.R().Get(server.URL); !errors.As(err, &quotaErr) {
+		t.Errorf("Get() error = %v, want ErrQuotaExceeded", err)
+	}
+	if got := meter.Stats(TrafficHost)["127.0.0.1"].Received; got > 1<<17 {
+		t.Errorf("received %d bytes, want transfer stopped near budget", got)
+	}
+}
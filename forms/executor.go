@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/go-per/simpkg/cache"
+	"github.com/go-per/simpkg/client"
 	"github.com/go-per/simpkg/format"
 	"github.com/go-per/simpkg/helpers"
 	"github.com/go-per/simpkg/i18n"
@@ -37,6 +38,7 @@ type FormExecutor struct {
 	target           any
 	successStatuses  []int
 	checkStatusCode  bool
+	decode           *client.DecodeMode
 	err              error
 	prepared         bool
 	restored         bool
@@ -183,6 +185,12 @@ func (e *FormExecutor) CheckStatusCode(v ...bool) *FormExecutor {
 	return e
 }
 
+// Decode sets response decoding of request, e.g. client.DecodeCharset for windows-1256 pages
+func (e *FormExecutor) Decode(mode client.DecodeMode) *FormExecutor {
+	e.decode = &mode
+	return e
+}
+
 // GetRawResponse returns response
 func (e *FormExecutor) GetRawResponse() *req.Response {
 	return e.resp
@@ -219,6 +227,10 @@ func (e *FormExecutor) Prepare() *FormExecutor {
 		return e
 	}
 
+	if e.decode != nil {
+		client.SetRequestDecode(e.request, *e.decode)
+	}
+
 	// clone new form
 	e.form = &Form{}
 	e.form.IsFormData = formItem.IsFormData
@@ -0,0 +1,53 @@
+package httpapi
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-per/simpkg/identity"
+	"github.com/go-per/simpkg/parse"
+)
+
+// HeaderUiToken is http header of ui token, Authorization bearer and token query are accepted too
+const HeaderUiToken = "X-Ui-Token"
+
+// AuthFunc authorizes a http request
+type AuthFunc func(r *http.Request) bool
+
+// ErrorResponse is body of error responses
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// UiTokenAuth authorizes requests by identity ui tokens
+func UiTokenAuth(i identity.IIdentity) AuthFunc {
+	return func(r *http.Request) bool {
+		token := r.Header.Get(HeaderUiToken)
+		if token == "" {
+			token = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		}
+		// event source can not set headers
+		if token == "" {
+			token = r.URL.Query().Get("token")
+		}
+		return token != "" && i.IsUiTokenValid(token)
+	}
+}
+
+// JSON writes json response
+func JSON(w http.ResponseWriter, status int, v any) {
+	body, err := parse.Encode(v)
+	if err != nil {
+		status = http.StatusInternalServerError
+		body, _ = parse.Encode(ErrorResponse{Error: err.Error()})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
+
+// Error writes error response
+func Error(w http.ResponseWriter, status int, message string) {
+	JSON(w, status, ErrorResponse{Error: message})
+}
@@ -0,0 +1,57 @@
+package httpapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-per/simpkg/identity"
+)
+
+func TestUiTokenAuth(t *testing.T) {
+	identity.Instance.AddClient("ui")
+	defer identity.Instance.RemoveClient("ui")
+	token, err := identity.Instance.GenerateUiToken("ui")
+	if err != nil {
+		t.Fatalf("GenerateUiToken() error = %v", err)
+	}
+	defer identity.Instance.RemoveUiToken(token)
+
+	auth := UiTokenAuth(identity.Instance)
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		query  string
+		want   bool
+	}{
+		{"header", HeaderUiToken, token, "", true},
+		{"bearer", "Authorization", "Bearer " + token, "", true},
+		{"query", "", "", token, true},
+		{"invalid token", HeaderUiToken, "invalid", "", false},
+		{"client id", "X-Client-Id", "ui", "", false},
+		{"missing", "", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/?token="+tt.query, nil)
+			if tt.header != "" {
+				r.Header.Set(tt.header, tt.value)
+			}
+			if got := auth(r); got != tt.want {
+				t.Errorf("UiTokenAuth() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestError(t *testing.T) {
+	w := httptest.NewRecorder()
+	Error(w, http.StatusBadRequest, "invalid")
+	if w.Code != http.StatusBadRequest || w.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("Error() status = %v, content type = %v", w.Code, w.Header().Get("Content-Type"))
+	}
+	if got, want := w.Body.String(), `{"error":"invalid"}`; got != want {
+		t.Errorf("Error() body = %v, want %v", got, want)
+	}
+}
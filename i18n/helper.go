@@ -1,6 +1,6 @@
 package i18n
 
-import "fmt"
+import "errors"
 
 // Instance is default instance
 var Instance II18N
@@ -22,10 +22,10 @@ func TranslateInLocale(text, locale string, args ...any) string {
 
 // TranslateAsError is a shortcut for Instance.TranslateAsError
 func TranslateAsError(text string, args ...any) error {
-	return fmt.Errorf(Instance.Translate(text, args...))
+	return errors.New(Instance.Translate(text, args...))
 }
 
 // TranslateAsErrorInLocale is a shortcut for Instance.TranslateAsErrorInLocale
 func TranslateAsErrorInLocale(text, locale string, args ...any) error {
-	return fmt.Errorf(Instance.TranslateInLocale(text, locale, args...))
+	return errors.New(Instance.TranslateInLocale(text, locale, args...))
 }
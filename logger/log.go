@@ -151,7 +151,7 @@ func (logger *Logger) Get(offset int, size int, fileName ...string) (response lo
 	}
 
 	var logs []LogItem
-	strContent, _ := strings.CutSuffix(string(content), ",")
+	strContent, _ := strings.CutSuffix(strings.TrimSpace(string(content)), ",")
 	jsonContent := []byte("[" + strContent + "]")
 	err = parse.Decode(jsonContent, &logs)
 	if err != nil {
@@ -31,6 +31,7 @@ type Proxy struct {
 	usageIds  []string
 	timers    map[string]*time.Timer
 	parsedUrl *urlPkg.URL
+	locker    sync.RWMutex
 }
 
 // NewProxy returns new proxy instance
@@ -67,11 +68,22 @@ func (proxy *Proxy) Url() urlPkg.URL {
 
 // IsInUse determine if proxy is in use
 func (proxy *Proxy) IsInUse() bool {
+	proxy.locker.RLock()
+	defer proxy.locker.RUnlock()
+
 	return proxy.isInUse
 }
 
 // IsUsedFor returns
 func (proxy *Proxy) IsUsedFor(usageId ...string) bool {
+	proxy.locker.RLock()
+	defer proxy.locker.RUnlock()
+
+	return proxy.isUsedFor(usageId...)
+}
+
+// isUsedFor returns true if proxy is used for usage id, caller must hold the lock
+func (proxy *Proxy) isUsedFor(usageId ...string) bool {
 	if usageId == nil || len(usageId) == 0 {
 		return false
 	}
@@ -81,15 +93,21 @@ func (proxy *Proxy) IsUsedFor(usageId ...string) bool {
 
 // isAvailable returns true if proxy is available
 func (proxy *Proxy) isAvailable(usageId ...string) bool {
-	return !proxy.isInUse && !proxy.IsUsedFor(usageId...)
+	proxy.locker.RLock()
+	defer proxy.locker.RUnlock()
+
+	return !proxy.isInUse && !proxy.isUsedFor(usageId...)
 }
 
 // use uses a proxy and return true, if false means proxy is not available
 func (proxy *Proxy) use(usageId ...string) bool {
+	proxy.locker.Lock()
+	defer proxy.locker.Unlock()
+
 	if proxy.isInUse {
 		return false
 	}
-	if usageId != nil && len(usageId) > 0 && proxy.IsUsedFor(usageId[0]) {
+	if usageId != nil && len(usageId) > 0 && proxy.isUsedFor(usageId[0]) {
 		return false
 	}
 
@@ -103,7 +121,9 @@ func (proxy *Proxy) use(usageId ...string) bool {
 
 // Release releases proxy
 func (proxy *Proxy) Release() {
+	proxy.locker.Lock()
 	proxy.isInUse = false
+	proxy.locker.Unlock()
 }
 
 // RemoveUsage remove proxy usage id
@@ -113,8 +133,11 @@ func (proxy *Proxy) RemoveUsage(usageId string) {
 	if ok && timer != nil {
 		timer.Stop()
 	}
-	proxy.usageIds = helpers.RemoveItem(proxy.usageIds, usageId)
 	timersLocker.Unlock()
+
+	proxy.locker.Lock()
+	proxy.usageIds = helpers.RemoveItem(proxy.usageIds, usageId)
+	proxy.locker.Unlock()
 }
 
 // RemoveUsageAfter remove proxy usage id after a while
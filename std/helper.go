@@ -23,6 +23,11 @@ func SetIsDebug(v bool) {
 	isDebugMode = v
 }
 
+// IsDebug returns debug mode
+func IsDebug() bool {
+	return isDebugMode
+}
+
 func Out(stdType OutType, format string, args ...any) {
 	if !isDebugMode && stdType != OutError {
 		return
@@ -62,7 +62,6 @@ func Match(matches []string, text string, normalizeValues ...bool) bool {
 					matchedItems++
 				}
 				continue
-				break
 			case "rn@":
 			case "rm@":
 				re := regexp.MustCompile(match)
@@ -74,7 +73,6 @@ func Match(matches []string, text string, normalizeValues ...bool) bool {
 					matchedItems++
 				}
 				continue
-				break
 			}
 		} else {
 			if strings.Contains(text, match) {
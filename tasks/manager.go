@@ -3,6 +3,7 @@ package tasks
 import (
 	"errors"
 	"sort"
+	"sync"
 )
 
 // IManager interface
@@ -34,6 +35,7 @@ type Manager struct {
 	current    *Task
 	isSorted   bool
 	isStarted  bool
+	locker     sync.RWMutex
 }
 
 // Items sortable
@@ -49,11 +51,24 @@ func New() IManager {
 
 // IsStarted returns start status
 func (manager *Manager) IsStarted() bool {
+	manager.locker.RLock()
+	defer manager.locker.RUnlock()
+
 	return manager.isStarted
 }
 
+// setStarted sets start status, tasks may be stopped from another goroutine
+func (manager *Manager) setStarted(started bool) {
+	manager.locker.Lock()
+	manager.isStarted = started
+	manager.locker.Unlock()
+}
+
 // Current current task
 func (manager *Manager) Current() *Task {
+	manager.locker.RLock()
+	defer manager.locker.RUnlock()
+
 	return manager.current
 }
 
@@ -93,7 +108,7 @@ func (manager *Manager) triggerOnTaskStatusChange(task *Task) {
 
 // Add task
 func (manager *Manager) Add(task *Task) {
-	task.status = StatusPending
+	task.SetStatus(StatusPending)
 	taskOnstart := task.OnStart
 	task.OnStart = func() {
 		manager.triggerOnTaskStatusChange(task)
@@ -114,12 +129,12 @@ func (manager *Manager) Add(task *Task) {
 	task.onRunEnd = func(task *Task) {
 		manager.triggerOnTaskStatusChange(task)
 		if task.err != nil {
-			manager.isStarted = false
+			manager.setStarted(false)
 			return
 		}
 
 		if !task.executeNextTask {
-			manager.isStarted = false
+			manager.setStarted(false)
 			return
 		}
 
@@ -134,12 +149,12 @@ func (manager *Manager) Add(task *Task) {
 // runNext run next task
 func (manager *Manager) runNext(current *Task) {
 	if current != nil && current.IsError() {
-		manager.isStarted = false
+		manager.setStarted(false)
 		return
 	}
 
 	// if stopped
-	if !manager.isStarted {
+	if !manager.IsStarted() {
 		return
 	}
 
@@ -151,7 +166,7 @@ func (manager *Manager) runNext(current *Task) {
 
 	task, completed := manager.move(true, currentIndex)
 	if completed {
-		manager.isStarted = false
+		manager.setStarted(false)
 		if manager.onComplete != nil {
 			manager.onComplete()
 		}
@@ -160,7 +175,7 @@ func (manager *Manager) runNext(current *Task) {
 
 	// if task already completed
 	if task == nil || (!completed && task == nil) {
-		manager.isStarted = false
+		manager.setStarted(false)
 		return
 	}
 
@@ -199,7 +214,9 @@ func (manager *Manager) move(next bool, current int) (*Task, bool) {
 
 // SetCurrent set current task
 func (manager *Manager) SetCurrent(task *Task) {
+	manager.locker.Lock()
 	manager.current = task
+	manager.locker.Unlock()
 }
 
 // Get find specified task
@@ -234,23 +251,24 @@ func (manager *Manager) Start() {
 		return
 	}
 
-	manager.isStarted = true
+	manager.setStarted(true)
 	if manager.onStart != nil {
 		manager.onStart()
 	}
 
-	if manager.current == nil {
+	current := manager.Current()
+	if current == nil {
 		manager.runNext(nil)
 		return
 	}
 
 	// run current task
-	manager.current.Start()
+	current.Start()
 }
 
 // Stop stops the tasks
 func (manager *Manager) Stop() {
-	manager.isStarted = false
+	manager.setStarted(false)
 	if manager.onStop != nil {
 		manager.onStop()
 	}
@@ -258,8 +276,8 @@ func (manager *Manager) Stop() {
 
 // Reset resets the manager
 func (manager *Manager) Reset() {
-	manager.isStarted = false
-	manager.current = nil
+	manager.setStarted(false)
+	manager.SetCurrent(nil)
 	for _, task := range manager.items {
 		task.reset()
 	}
@@ -1,6 +1,10 @@
 package tasks
 
-import "time"
+import (
+	"fmt"
+	"sync"
+	"time"
+)
 
 // Status is Task status
 type Status string
@@ -52,20 +56,36 @@ type Task struct {
 	status          Status
 	executeNextTask bool
 	attempts        int
+	locker          sync.RWMutex // guards status and err, tasks are inspected from other goroutines
 }
 
 // Status returns task status
 func (task *Task) Status() Status {
+	task.locker.RLock()
+	defer task.locker.RUnlock()
+
 	return task.status
 }
 
 // SetStatus set status
 func (task *Task) SetStatus(status Status) {
+	task.locker.Lock()
 	task.status = status
+	task.locker.Unlock()
+}
+
+// setError sets task err
+func (task *Task) setError(err error) {
+	task.locker.Lock()
+	task.err = err
+	task.locker.Unlock()
 }
 
 // Error returns task err
 func (task *Task) Error() error {
+	task.locker.RLock()
+	defer task.locker.RUnlock()
+
 	return task.err
 }
 
@@ -76,17 +96,29 @@ func (task *Task) Attempts() int {
 
 // IsError returns true if task has error
 func (task *Task) IsError() bool {
-	return task.err != nil
+	return task.Error() != nil
 }
 
 // IsDone returns true if task is done
 func (task *Task) IsDone() bool {
-	return task.status == StatusSuccess || task.status == StatusFail
+	status := task.Status()
+	return status == StatusSuccess || status == StatusFail
 }
 
 // IsSuccess returns true if task is success
 func (task *Task) IsSuccess() bool {
-	return task.status == StatusSuccess
+	return task.Status() == StatusSuccess
+}
+
+// call runs handler, panics are returned as error
+func (task *Task) call() (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("task panic [%v]: %v", task.Name, r)
+		}
+	}()
+
+	return task.Handler()
 }
 
 // pause to next retry
@@ -98,7 +130,7 @@ func (task *Task) pause() {
 
 // reset statues
 func (task *Task) reset() {
-	task.err = nil
+	task.setError(nil)
 	task.SetStatus(StatusPending)
 	task.executeNextTask = true
 	task.attempts = 0
@@ -152,14 +184,14 @@ func (task *Task) Start() {
 		}
 
 		// run the task and get result
-		result := task.Handler()
+		result := task.call()
 		err, hasErr := result.(error)
 		if !hasErr {
-			task.err = nil
+			task.setError(nil)
 			break
 		}
 
-		task.err = err
+		task.setError(err)
 		task.SetStatus(StatusFail)
 		if task.ErrorMessage != nil {
 			task.Message = task.ErrorMessage(task, err)
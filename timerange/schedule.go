@@ -0,0 +1,393 @@
+package timerange
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-per/simpkg/format"
+	"github.com/go-per/simpkg/types"
+)
+
+// weekdays by name
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// months by name
+var months = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+// Schedule is a set of calendar windows in a timezone, it is active while any window is open,
+// windows are compared with wall clock time so they follow daylight saving changes
+//
+//	{
+//	  "timezone": "Europe/Berlin",
+//	  "windows": [{"days": ["mon-fri"], "start": "09:00", "end": "17:00"}],
+//	  "cron": [{"start": "0 22 * * sat", "duration": "6h"}]
+//	}
+type Schedule struct {
+	location *time.Location
+	windows  []Window
+	crons    []CronWindow
+}
+
+// Window is a daily window, end before start crosses midnight, empty days is every day
+type Window struct {
+	Days  []string `json:"days"`
+	Start string   `json:"start"`
+	End   string   `json:"end"`
+
+	days  [7]bool
+	start int // seconds of day
+	end   int
+}
+
+// CronWindow is a window which opens at cron times and stays open for duration
+type CronWindow struct {
+	Start    string         `json:"start"` // minute hour day-of-month month day-of-week
+	Duration types.Duration `json:"duration"`
+
+	expr *cron
+}
+
+// scheduleConfig is json form of schedule
+type scheduleConfig struct {
+	Timezone string       `json:"timezone"`
+	Windows  []Window     `json:"windows"`
+	Cron     []CronWindow `json:"cron"`
+}
+
+// NewSchedule returns empty schedule of timezone, empty timezone is local time
+func NewSchedule(timezone string) (*Schedule, error) {
+	loc := time.Local
+	if timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(timezone); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Schedule{location: loc}, nil
+}
+
+// ParseSchedule parses json schedule
+func ParseSchedule(data []byte) (*Schedule, error) {
+	s := &Schedule{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// UnmarshalJSON parses and validates json schedule
+func (s *Schedule) UnmarshalJSON(b []byte) error {
+	var config scheduleConfig
+	if err := json.Unmarshal(b, &config); err != nil {
+		return err
+	}
+
+	schedule, err := NewSchedule(config.Timezone)
+	if err != nil {
+		return err
+	}
+	for _, w := range config.Windows {
+		if err = schedule.AddWindow(w.Days, w.Start, w.End); err != nil {
+			return err
+		}
+	}
+	for _, c := range config.Cron {
+		if err = schedule.AddCron(c.Start, c.Duration.Duraion()); err != nil {
+			return err
+		}
+	}
+
+	*s = *schedule
+	return nil
+}
+
+// MarshalJSON returns json schedule
+func (s *Schedule) MarshalJSON() ([]byte, error) {
+	return json.Marshal(scheduleConfig{Timezone: s.Location().String(), Windows: s.windows, Cron: s.crons})
+}
+
+// AddWindow adds daily window, days are names or ranges like "mon-fri", times are "15:04"
+func (s *Schedule) AddWindow(days []string, start, end string) error {
+	w := Window{Days: days, Start: start, End: end}
+
+	var err error
+	if w.days, err = parseDays(days); err != nil {
+		return err
+	}
+	if w.start, err = parseClock(start); err != nil {
+		return err
+	}
+	if w.end, err = parseClock(end); err != nil {
+		return err
+	}
+	if w.start == w.end {
+		return format.Error("Window start and end are equal [%v]", start)
+	}
+
+	s.windows = append(s.windows, w)
+	return nil
+}
+
+// AddCron adds window which opens at cron expression times and stays open for d
+func (s *Schedule) AddCron(expr string, d time.Duration) error {
+	if d < time.Minute {
+		return format.Error("Cron window duration must be at least a minute [%v]", expr)
+	}
+	c, err := parseCron(expr)
+	if err != nil {
+		return err
+	}
+
+	s.crons = append(s.crons, CronWindow{Start: expr, Duration: types.Duration(d), expr: c})
+	return nil
+}
+
+// Location returns schedule timezone
+func (s *Schedule) Location() *time.Location {
+	if s.location == nil {
+		return time.Local
+	}
+	return s.location
+}
+
+// IsEmpty reports whether schedule has no window
+func (s *Schedule) IsEmpty() bool {
+	return len(s.windows) == 0 && len(s.crons) == 0
+}
+
+// Active reports whether any window is open at t, overlapping windows are merged
+func (s *Schedule) Active(t time.Time) bool {
+	loc := s.Location()
+	local := t.In(loc)
+	for _, w := range s.windows {
+		if w.active(local) {
+			return true
+		}
+	}
+	for _, c := range s.crons {
+		if c.active(t, loc) {
+			return true
+		}
+	}
+	return false
+}
+
+// active reports whether window is open at local time
+func (w Window) active(local time.Time) bool {
+	seconds := local.Hour()*3600 + local.Minute()*60 + local.Second()
+	day := local.Weekday()
+
+	if w.start < w.end {
+		return w.days[day] && seconds >= w.start && seconds < w.end
+	}
+
+	// window crosses midnight, after midnight belongs to the previous day
+	previous := (day + 6) % 7
+	return (w.days[day] && seconds >= w.start) || (w.days[previous] && seconds < w.end)
+}
+
+// active reports whether a cron time in (t-duration, t] opened the window, cron times are wall clock
+// times, times skipped by daylight saving fire after the change and repeated times fire once
+func (c CronWindow) active(t time.Time, loc *time.Location) bool {
+	d := c.Duration.Duraion()
+	local := t.In(loc)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+
+	// days whose cron times can reach t
+	for i := 0; i <= int(d/(24*time.Hour))+1; i++ {
+		date := day.AddDate(0, 0, -i)
+		if !c.expr.matchDay(date) {
+			continue
+		}
+		for hour := 0; hour < 24; hour++ {
+			if !c.expr.hour[hour] {
+				continue
+			}
+			for minute := 0; minute < 60; minute++ {
+				if !c.expr.minute[minute] {
+					continue
+				}
+				start := time.Date(date.Year(), date.Month(), date.Day(), hour, minute, 0, 0, loc)
+				if !start.After(t) && t.Sub(start) < d {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// cron is parsed cron expression
+type cron struct {
+	minute  [60]bool
+	hour    [24]bool
+	dom     [32]bool
+	month   [13]bool
+	dow     [7]bool
+	domStar bool
+	dowStar bool
+}
+
+// parseCron parses five field cron expression
+func parseCron(expr string) (*cron, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, format.Error("Cron expression must have 5 fields [%v]", expr)
+	}
+
+	c := &cron{domStar: fields[2] == "*", dowStar: fields[4] == "*"}
+	parts := []struct {
+		field string
+		min   int
+		max   int
+		names map[string]int
+		set   func(int)
+	}{
+		{fields[0], 0, 59, nil, func(v int) { c.minute[v] = true }},
+		{fields[1], 0, 23, nil, func(v int) { c.hour[v] = true }},
+		{fields[2], 1, 31, nil, func(v int) { c.dom[v] = true }},
+		{fields[3], 1, 12, months, func(v int) { c.month[v] = true }},
+		{fields[4], 0, 7, weekdayNumbers(), func(v int) { c.dow[v%7] = true }},
+	}
+	for _, p := range parts {
+		if err := parseCronField(p.field, p.min, p.max, p.names, p.set); err != nil {
+			return nil, format.Error("Invalid cron expression [%v]: %v", expr, err)
+		}
+	}
+	return c, nil
+}
+
+// matchDay reports whether cron runs on date, day of month and day of week match either when both are set
+func (c *cron) matchDay(date time.Time) bool {
+	if !c.month[date.Month()] {
+		return false
+	}
+
+	dom, dow := c.dom[date.Day()], c.dow[date.Weekday()]
+	switch {
+	case c.domStar && c.dowStar:
+		return true
+	case c.domStar:
+		return dow
+	case c.dowStar:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+// parseCronField parses lists, ranges and steps of a cron field
+func parseCronField(field string, min, max int, names map[string]int, set func(int)) error {
+	for _, item := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(item, "/"); i >= 0 {
+			var err error
+			if step, err = strconv.Atoi(item[i+1:]); err != nil || step < 1 {
+				return format.Error("invalid step %v", item)
+			}
+			item = item[:i]
+		}
+
+		from, to := min, max
+		if item != "*" {
+			bounds := strings.SplitN(item, "-", 2)
+			var err error
+			if from, err = cronValue(bounds[0], names); err != nil {
+				return err
+			}
+			to = from
+			if len(bounds) == 2 {
+				if to, err = cronValue(bounds[1], names); err != nil {
+					return err
+				}
+			} else if step > 1 {
+				to = max
+			}
+		}
+		if from < min || to > max || from > to {
+			return format.Error("%v is out of range %d-%d", item, min, max)
+		}
+
+		for v := from; v <= to; v += step {
+			set(v)
+		}
+	}
+	return nil
+}
+
+// cronValue parses number or name of cron field
+func cronValue(s string, names map[string]int) (int, error) {
+	if v, ok := names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, format.Error("invalid value %v", s)
+	}
+	return v, nil
+}
+
+// weekdayNumbers returns weekday names as cron values
+func weekdayNumbers() map[string]int {
+	names := make(map[string]int, len(weekdays))
+	for name, day := range weekdays {
+		names[name] = int(day)
+	}
+	return names
+}
+
+// parseDays parses day names and ranges, empty days is every day
+func parseDays(days []string) (parsed [7]bool, err error) {
+	if len(days) == 0 {
+		return [7]bool{true, true, true, true, true, true, true}, nil
+	}
+
+	for _, item := range days {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if item == "*" {
+			return parseDays(nil)
+		}
+
+		bounds := strings.SplitN(item, "-", 2)
+		from, ok := weekdays[bounds[0]]
+		if !ok {
+			return parsed, format.Error("Invalid day [%v]", item)
+		}
+		to := from
+		if len(bounds) == 2 {
+			if to, ok = weekdays[bounds[1]]; !ok {
+				return parsed, format.Error("Invalid day [%v]", item)
+			}
+		}
+
+		// ranges may wrap, e.g. fri-mon
+		for day := from; ; day = (day + 1) % 7 {
+			parsed[day] = true
+			if day == to {
+				break
+			}
+		}
+	}
+	return parsed, nil
+}
+
+// parseClock parses "15:04" to seconds of day, "24:00" is end of day
+func parseClock(s string) (int, error) {
+	if s == "24:00" {
+		return 24 * 3600, nil
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, format.Error("Invalid time [%v]", s)
+	}
+	return t.Hour()*3600 + t.Minute()*60, nil
+}
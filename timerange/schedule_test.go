@@ -0,0 +1,78 @@
+package timerange
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSchedule_Active(t *testing.T) {
+	schedule, err := ParseSchedule([]byte(`{
+		"timezone": "Europe/Berlin",
+		"windows": [
+			{"days": ["mon-fri"], "start": "09:00", "end": "12:00"},
+			{"days": ["mon-fri"], "start": "11:00", "end": "17:00"},
+			{"days": ["fri"], "start": "22:00", "end": "02:00"}
+		],
+		"cron": [{"start": "30 2 * * sun", "duration": "1h"}]
+	}`))
+	if err != nil {
+		t.Fatalf("ParseSchedule() error = %v", err)
+	}
+
+	// 2024-03-31 is a sunday, clocks go from 02:00 to 03:00 in Berlin
+	tests := []struct {
+		name string
+		time string
+		want bool
+	}{
+		{"inside window", "2024-03-25T10:00:00+01:00", true},
+		{"overlapping windows", "2024-03-25T16:59:00+01:00", true},
+		{"window end", "2024-03-25T17:00:00+01:00", false},
+		{"weekend", "2024-03-23T10:00:00+01:00", false},
+		{"before midnight", "2024-03-29T23:00:00+01:00", true},
+		{"after midnight", "2024-03-30T01:59:00+01:00", true},
+		{"after midnight of other day", "2024-03-31T01:00:00+01:00", false},
+		{"other timezone", "2024-03-25T08:30:00Z", true},
+		{"skipped cron time fires after change", "2024-03-31T03:45:00+02:00", true},
+		{"skipped cron window end", "2024-03-31T04:30:00+02:00", false},
+		{"summer time window", "2024-04-01T09:30:00+02:00", true},
+		{"summer time utc inside window", "2024-04-01T07:30:00Z", true},
+		{"summer time utc before window", "2024-04-01T06:30:00Z", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			at, err := time.Parse(time.RFC3339, tt.time)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := schedule.Active(at); got != tt.want {
+				t.Errorf("Active(%v) = %v, want %v", tt.time, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSchedule(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{"empty", `{}`, false},
+		{"cron lists and steps", `{"cron": [{"start": "*/15 9-17 1,15 jan-jun mon-fri", "duration": "10m"}]}`, false},
+		{"unknown timezone", `{"timezone": "Mars/Base"}`, true},
+		{"unknown day", `{"windows": [{"days": ["someday"], "start": "09:00", "end": "10:00"}]}`, true},
+		{"invalid time", `{"windows": [{"start": "9am", "end": "10:00"}]}`, true},
+		{"equal times", `{"windows": [{"start": "09:00", "end": "09:00"}]}`, true},
+		{"cron fields", `{"cron": [{"start": "0 9 * *", "duration": "1h"}]}`, true},
+		{"cron range", `{"cron": [{"start": "0 25 * * *", "duration": "1h"}]}`, true},
+		{"short duration", `{"cron": [{"start": "0 9 * * *", "duration": "30s"}]}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseSchedule([]byte(tt.data)); (err != nil) != tt.wantErr {
+				t.Errorf("ParseSchedule() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -23,6 +23,22 @@ func (t JSONTime) MarshalJSON() ([]byte, error) {
 	return []byte(stamp), nil
 }
 
+// UnmarshalJSON json time
+func (t *JSONTime) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil || s == "" {
+		*t = JSONTime{}
+		return nil
+	}
+
+	parsed, err := time.ParseInLocation("2006-01-02 15:04:05", s, time.Local)
+	if err != nil {
+		return err
+	}
+	*t = JSONTime(parsed)
+	return nil
+}
+
 // MarshalJSON json date
 func (t JSONDate) MarshalJSON() ([]byte, error) {
 	stamp := fmt.Sprintf("\"%s\"", time.Time(t).Format("2006-01-02"))
@@ -0,0 +1,178 @@
+package workman
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// EventWorkerTags is dispatched with WorkerOnTagsChange when worker joins or leaves groups
+const EventWorkerTags WorkerEvent = "worker.tags"
+
+// ITagged is implemented by workers which belong to groups
+type ITagged interface {
+	Tags() []string
+}
+
+// ITaggable is implemented by workers whose tags can be changed at runtime
+type ITaggable interface {
+	ITagged
+	SetTags(tags ...string)
+}
+
+// WorkerOnTagsChange struct
+type WorkerOnTagsChange struct {
+	Added   []string // joined groups
+	Removed []string // left groups
+	Worker  IWorker
+}
+
+// Filter returns workers which have all tags, without tags all workers are returned
+func (m *Manager) Filter(tags ...string) []IWorker {
+	tags = normalizeTags(tags)
+
+	workers := make([]IWorker, 0)
+	for _, worker := range m.Workers() {
+		matched := true
+		for _, tag := range tags {
+			if !hasTag(worker, tag) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			workers = append(workers, worker)
+		}
+	}
+	return workers
+}
+
+// Groups returns workers count of every tag
+func (m *Manager) Groups() map[string]int {
+	groups := make(map[string]int)
+	for _, worker := range m.Workers() {
+		for _, tag := range workerTags(worker) {
+			groups[tag]++
+		}
+	}
+	return groups
+}
+
+// SetWorkerTags changes tags of worker and dispatches membership changes
+func (m *Manager) SetWorkerTags(id string, tags ...string) error {
+	worker, ok := m.Get(id)
+	if !ok {
+		return errors.New("worker not found")
+	}
+	taggable, ok := worker.(ITaggable)
+	if !ok {
+		return errors.New("worker tags can not be changed")
+	}
+
+	before := workerTags(worker)
+	taggable.SetTags(tags...)
+	m.dispatchTags(worker, before, workerTags(worker))
+	return nil
+}
+
+// StartGroup starts workers of group under supervision
+func (m *Manager) StartGroup(tag string) error {
+	return m.eachInGroup(tag, m.StartWorker)
+}
+
+// StopGroup stops supervised workers of group
+func (m *Manager) StopGroup(tag string) error {
+	return m.eachInGroup(tag, m.StopWorker)
+}
+
+// RestartGroup stops and starts workers of group, stopped workers are started too
+func (m *Manager) RestartGroup(tag string) error {
+	return m.eachInGroup(tag, func(id string) error {
+		_ = m.StopWorker(id)
+		return m.StartWorker(id)
+	})
+}
+
+// eachInGroup calls fn with id of every worker of group, errors are joined
+func (m *Manager) eachInGroup(tag string, fn func(id string) error) error {
+	var msgs []string
+	for _, worker := range m.Filter(tag) {
+		if err := fn(worker.GetID()); err != nil {
+			msgs = append(msgs, worker.GetID()+": "+err.Error())
+		}
+	}
+	if len(msgs) > 0 {
+		return errors.New("group " + tag + " failed: " + strings.Join(msgs, "; "))
+	}
+	return nil
+}
+
+// dispatchTags dispatches membership changes between tags
+func (m *Manager) dispatchTags(worker IWorker, before, after []string) {
+	event := WorkerOnTagsChange{
+		Added:   diffTags(after, before),
+		Removed: diffTags(before, after),
+		Worker:  worker,
+	}
+	if len(event.Added) == 0 && len(event.Removed) == 0 {
+		return
+	}
+	if len(event.Removed) > 0 {
+		m.pruneSelection(nil)
+	}
+
+	m.eventbus.Dispatch(string(EventWorkerTags), event)
+}
+
+// workerTags returns tags of worker
+func workerTags(worker IWorker) []string {
+	if tagged, ok := worker.(ITagged); ok {
+		return normalizeTags(tagged.Tags())
+	}
+	return nil
+}
+
+// hasTag reports whether worker has tag
+func hasTag(worker IWorker, tag string) bool {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	for _, t := range workerTags(worker) {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// diffTags returns tags of a which are not in b
+func diffTags(a, b []string) []string {
+	var diff []string
+	for _, tag := range a {
+		found := false
+		for _, t := range b {
+			if t == tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			diff = append(diff, tag)
+		}
+	}
+	return diff
+}
+
+// normalizeTags returns sorted lower case tags without empty and duplicate tags
+func normalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	sort.Strings(normalized)
+	return normalized
+}
@@ -0,0 +1,117 @@
+package workman
+
+import (
+	"testing"
+)
+
+func TestManager_Filter(t *testing.T) {
+	m := NewManager(func() IWorker { return &Worker{} })
+	m.SetRootPath(t.TempDir())
+	for i, config := range []string{
+		`{"id":"a","tags":["shop"],"group":"gold"}`,
+		`{"id":"b","tags":["Shop "]}`,
+		`{"id":"c","tags":["news"],"group":"gold"}`,
+	} {
+		if _, err := m.Add(i, []byte(config)); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		tags []string
+		want string
+	}{
+		{nil, "a,b,c"},
+		{[]string{"shop"}, "a,b"},
+		{[]string{"gold"}, "a,c"},
+		{[]string{"shop", "gold"}, "a"},
+		{[]string{"missing"}, ""},
+	}
+	for _, tt := range tests {
+		var ids []string
+		for _, w := range m.Filter(tt.tags...) {
+			ids = append(ids, w.GetID())
+		}
+		if got := joinIDs(ids); got != tt.want {
+			t.Errorf("Filter(%v) = %v, want %v", tt.tags, got, tt.want)
+		}
+	}
+
+	// groups rotate independently
+	var got []string
+	for _, tag := range []string{"shop", "gold", "shop", "gold", "shop"} {
+		got = append(got, m.NextIn(tag).GetID())
+	}
+	if want := "a,a,b,c,a"; joinIDs(got) != want {
+		t.Errorf("NextIn() = %v, want %v", joinIDs(got), want)
+	}
+
+	// membership changes are dispatched
+	var event WorkerOnTagsChange
+	m.Eventbus().Subscribe(string(EventWorkerTags), func(v ...any) { event = v[0].(WorkerOnTagsChange) })
+	if err := m.SetWorkerTags("b", "news", "gold"); err != nil {
+		t.Fatalf("SetWorkerTags() error = %v", err)
+	}
+	if got := joinIDs(event.Added) + "|" + joinIDs(event.Removed); got != "gold,news|shop" {
+		t.Errorf("tags event added|removed = %v, want gold,news|shop", got)
+	}
+	if got := m.Groups(); got["gold"] != 3 || got["shop"] != 1 {
+		t.Errorf("Groups() = %v, want gold 3 and shop 1", got)
+	}
+
+	// bulk operations
+	if err := m.StartGroup("news"); err != nil {
+		t.Fatalf("StartGroup() error = %v", err)
+	}
+	if err := m.StopGroup("gold"); err == nil {
+		t.Errorf("StopGroup() of partly started group, want error for worker a")
+	}
+	for id, want := range map[string]WorkerState{"a": WorkerStopped, "b": WorkerStopped, "c": WorkerStopped} {
+		if state, _ := m.WorkerState(id); state != want {
+			t.Errorf("WorkerState(%v) = %v, want %v", id, state, want)
+		}
+	}
+}
+
+func TestManager_NextIn_Prune(t *testing.T) {
+	m := NewManager(func() IWorker { return &Worker{} })
+	m.SetRootPath(t.TempDir())
+	for i, config := range []string{`{"id":"a","tags":["shop"]}`, `{"id":"b","tags":["shop"]}`, `{"id":"c","tags":["news"]}`} {
+		if _, err := m.Add(i, []byte(config)); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+	for _, tag := range []string{"", "shop", "news"} {
+		m.NextIn(tag)
+	}
+
+	// removed worker is not kept by selection state
+	a, _ := m.Get("a")
+	m.Remove("a")
+	m.Remove("c")
+	m.selectWorkerLocker.RLock()
+	for tag, state := range m.roundRobins {
+		if state.last == a {
+			t.Errorf("round-robin of %q keeps removed worker", tag)
+		}
+	}
+	_, news := m.roundRobins["news"]
+	m.selectWorkerLocker.RUnlock()
+	if news {
+		t.Errorf("round-robin of empty group news is kept")
+	}
+	if got := m.NextIn("shop").GetID(); got != "b" {
+		t.Errorf("NextIn(shop) after Remove() = %v, want b", got)
+	}
+
+	// group emptied by tag change is dropped
+	if err := m.SetWorkerTags("b", "news"); err != nil {
+		t.Fatalf("SetWorkerTags() error = %v", err)
+	}
+	m.selectWorkerLocker.RLock()
+	_, shop := m.roundRobins["shop"]
+	m.selectWorkerLocker.RUnlock()
+	if shop {
+		t.Errorf("round-robin of empty group shop is kept")
+	}
+}
@@ -0,0 +1,403 @@
+package workman
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-per/simpkg/httpapi"
+	"github.com/go-per/simpkg/identity"
+	"github.com/go-per/simpkg/parse"
+)
+
+// WorkerInfo is worker in http responses
+type WorkerInfo struct {
+	ID          string      `json:"id"`
+	Index       int         `json:"index"`
+	State       WorkerState `json:"state,omitempty"`
+	Paused      bool        `json:"paused"`
+	PauseReason string      `json:"pause_reason,omitempty"`
+	Proxy       string      `json:"proxy,omitempty"`
+	Tags        []string    `json:"tags,omitempty"`
+	Details     any         `json:"details,omitempty"`
+}
+
+// TaskInfo is task in http responses
+type TaskInfo struct {
+	Name     string `json:"name"`
+	Label    string `json:"label,omitempty"`
+	Order    int    `json:"order"`
+	Status   string `json:"status"`
+	Attempts int    `json:"attempts"`
+	Error    string `json:"error,omitempty"`
+	Message  any    `json:"message,omitempty"`
+	Current  bool   `json:"current"`
+}
+
+// StreamEvent is manager event sent to event stream
+type StreamEvent struct {
+	Event  string `json:"event"`
+	Worker string `json:"worker,omitempty"`
+	Data   any    `json:"data,omitempty"`
+	Time   int64  `json:"time"`
+}
+
+// Handler serves manager control plane over http
+//
+//	GET    /workers                    list workers with details, query: tag
+//	POST   /workers                    add worker, body is worker config, query: index
+//	GET    /workers/{id}               worker details
+//	DELETE /workers/{id}               stop and remove worker
+//	POST   /workers/{id}/{start|stop|restart}
+//	GET    /workers/{id}/tasks         task statuses
+//	GET    /workers/{id}/logs          logs page, query: offset, size, file
+//	GET    /workers/{id}/logs/files    log files
+//	GET    /events                     server sent events of workers
+type Handler struct {
+	manager   IManager
+	auth      httpapi.AuthFunc
+	keepAlive time.Duration
+	streams   map[chan StreamEvent]bool
+	locker    sync.RWMutex
+}
+
+// NewHandler returns new http handler of manager, requests are authorized by ui tokens of identity.Instance
+func NewHandler(manager IManager) *Handler {
+	h := &Handler{
+		manager:   manager,
+		auth:      httpapi.UiTokenAuth(identity.Instance),
+		keepAlive: time.Second * 30,
+		streams:   make(map[chan StreamEvent]bool),
+	}
+
+	// fan out events to streams
+	for _, event := range []WorkerEvent{EventWorkerAddRemove, EventWorkerLoad, EventWorkerPause, EventWorkerState, EventWorkerReload, EventWorkerTags, EventWorkerSchedule} {
+		event := event
+		manager.Eventbus().Subscribe(string(event), func(v ...any) {
+			if len(v) > 0 {
+				h.broadcast(streamEvent(event, v[0]))
+			}
+		})
+	}
+
+	return h
+}
+
+// SetAuth sets request authorization function, nil allows all requests
+func (h *Handler) SetAuth(fn httpapi.AuthFunc) *Handler {
+	h.auth = fn
+	return h
+}
+
+// SetKeepAlive sets keep alive interval of event stream
+func (h *Handler) SetKeepAlive(d time.Duration) *Handler {
+	h.keepAlive = d
+	return h
+}
+
+// ServeHTTP implements http.Handler
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.auth != nil && !h.auth(r) {
+		httpapi.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case len(parts) == 1 && parts[0] == "events" && r.Method == http.MethodGet:
+		h.stream(w, r)
+		return
+	case len(parts) == 0 || parts[0] != "workers":
+		httpapi.Error(w, http.StatusNotFound, "not found")
+		return
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		h.listWorkers(w, r)
+		return
+	case len(parts) == 1 && r.Method == http.MethodPost:
+		h.addWorker(w, r)
+		return
+	case len(parts) == 1:
+		httpapi.Error(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	worker, ok := h.manager.Get(parts[1])
+	if !ok {
+		httpapi.Error(w, http.StatusNotFound, "worker not found")
+		return
+	}
+	id := worker.GetID()
+
+	action := strings.Join(parts[2:], "/")
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		httpapi.JSON(w, http.StatusOK, h.info(worker))
+	case action == "" && r.Method == http.MethodDelete:
+		h.manager.Remove(id)
+		w.WriteHeader(http.StatusNoContent)
+	case action == "start" && r.Method == http.MethodPost:
+		h.result(w, worker, h.manager.StartWorker(id))
+	case action == "stop" && r.Method == http.MethodPost:
+		h.result(w, worker, h.manager.StopWorker(id))
+	case action == "restart" && r.Method == http.MethodPost:
+		_ = h.manager.StopWorker(id)
+		h.result(w, worker, h.manager.StartWorker(id))
+	case action == "tasks" && r.Method == http.MethodGet:
+		h.tasks(w, worker)
+	case action == "logs" && r.Method == http.MethodGet:
+		h.logs(w, r, worker)
+	case action == "logs/files" && r.Method == http.MethodGet:
+		if worker.Logger() == nil {
+			httpapi.JSON(w, http.StatusOK, []string{})
+			return
+		}
+		httpapi.JSON(w, http.StatusOK, worker.Logger().LogFiles())
+	default:
+		httpapi.Error(w, http.StatusNotFound, "not found")
+	}
+}
+
+// listWorkers writes workers, query: tag (repeatable)
+func (h *Handler) listWorkers(w http.ResponseWriter, r *http.Request) {
+	workers := h.manager.Filter(r.URL.Query()["tag"]...)
+	infos := make([]WorkerInfo, 0, len(workers))
+	for _, worker := range workers {
+		infos = append(infos, h.info(worker))
+	}
+
+	httpapi.JSON(w, http.StatusOK, infos)
+}
+
+// addWorker adds worker from uploaded config, added worker is not saved to workers directory
+func (h *Handler) addWorker(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		httpapi.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !json.Valid(body) {
+		httpapi.Error(w, http.StatusBadRequest, "config is not valid json")
+		return
+	}
+
+	index := h.manager.WorkersCount()
+	if v := r.URL.Query().Get("index"); v != "" {
+		if index, err = strconv.Atoi(v); err != nil {
+			httpapi.Error(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	worker, err := h.manager.Add(index, body)
+	if err != nil {
+		httpapi.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	httpapi.JSON(w, http.StatusCreated, h.info(worker))
+}
+
+// tasks writes task statuses of worker
+func (h *Handler) tasks(w http.ResponseWriter, worker IWorker) {
+	infos := make([]TaskInfo, 0)
+	tm := worker.TaskManager()
+	if tm == nil {
+		httpapi.JSON(w, http.StatusOK, infos)
+		return
+	}
+
+	current := tm.Current()
+	for _, task := range tm.Items() {
+		info := TaskInfo{
+			Name:     task.Name,
+			Label:    task.Label,
+			Order:    task.Order,
+			Status:   string(task.Status()),
+			Attempts: task.Attempts(),
+			Message:  task.Message,
+			Current:  task == current,
+		}
+		if err := task.Error(); err != nil {
+			info.Error = err.Error()
+		}
+		infos = append(infos, info)
+	}
+
+	httpapi.JSON(w, http.StatusOK, infos)
+}
+
+// logs writes logs page of worker
+func (h *Handler) logs(w http.ResponseWriter, r *http.Request, worker IWorker) {
+	if worker.Logger() == nil {
+		httpapi.Error(w, http.StatusNotFound, "worker has no logger")
+		return
+	}
+
+	query := r.URL.Query()
+	offset, _ := strconv.Atoi(query.Get("offset"))
+	size, _ := strconv.Atoi(query.Get("size"))
+	if size <= 0 {
+		size = 20
+	}
+
+	var files []string
+	if file := query.Get("file"); file != "" {
+		// only listed files of worker can be read
+		if !isLogFile(worker.Logger().LogFiles(), file) {
+			httpapi.Error(w, http.StatusBadRequest, "invalid log file")
+			return
+		}
+		files = append(files, file)
+	}
+
+	httpapi.JSON(w, http.StatusOK, worker.Logger().Get(offset, size, files...))
+}
+
+// stream writes manager events as server sent events until request is done
+func (h *Handler) stream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		httpapi.Error(w, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+
+	ch := make(chan StreamEvent, 64)
+	h.locker.Lock()
+	h.streams[ch] = true
+	h.locker.Unlock()
+	defer func() {
+		h.locker.Lock()
+		delete(h.streams, ch)
+		h.locker.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ticker := time.NewTicker(h.keepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			_, _ = io.WriteString(w, ": ping\n\n")
+		case event := <-ch:
+			body, err := parse.Encode(event)
+			if err != nil {
+				continue
+			}
+			_, _ = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Event, body)
+		}
+		flusher.Flush()
+	}
+}
+
+// broadcast sends event to streams, slow streams miss events
+func (h *Handler) broadcast(event StreamEvent) {
+	h.locker.RLock()
+	defer h.locker.RUnlock()
+
+	for ch := range h.streams {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// info returns worker info
+func (h *Handler) info(worker IWorker) WorkerInfo {
+	info := WorkerInfo{
+		ID:      worker.GetID(),
+		Index:   worker.Index(),
+		Details: worker.GetDetails(),
+	}
+	info.State, _ = h.manager.WorkerState(info.ID)
+	info.Proxy = h.manager.WorkerProxy(info.ID)
+	info.Tags = workerTags(worker)
+	if pausable, ok := worker.(IPausable); ok {
+		info.Paused = pausable.IsPaused()
+	}
+	if p, ok := worker.(interface{ PauseReason() string }); ok {
+		info.PauseReason = p.PauseReason()
+	}
+	return info
+}
+
+// result writes worker info or error of worker action
+func (h *Handler) result(w http.ResponseWriter, worker IWorker, err error) {
+	if err != nil {
+		httpapi.Error(w, http.StatusConflict, err.Error())
+		return
+	}
+
+	httpapi.JSON(w, http.StatusOK, h.info(worker))
+}
+
+// streamEvent converts manager event to stream event
+func streamEvent(event WorkerEvent, v any) StreamEvent {
+	e := StreamEvent{Event: string(event), Time: time.Now().Unix()}
+	switch data := v.(type) {
+	case WorkerOnStateChange:
+		e.Worker = data.Worker.GetID()
+		payload := map[string]any{"from": data.From, "to": data.To, "restarts": data.Restarts}
+		if data.Err != nil {
+			payload["error"] = data.Err.Error()
+		}
+		e.Data = payload
+	case WorkerOnAddRemove:
+		e.Worker = data.Worker.GetID()
+		e.Data = map[string]any{"added": data.Added, "removed": data.Removed}
+	case WorkerOnSchedule:
+		e.Worker = data.Worker.GetID()
+		payload := map[string]any{"active": data.Active}
+		if data.Err != nil {
+			payload["error"] = data.Err.Error()
+		}
+		e.Data = payload
+	case WorkerOnTagsChange:
+		e.Worker = data.Worker.GetID()
+		e.Data = map[string]any{"added": data.Added, "removed": data.Removed}
+	case WorkerOnPause:
+		e.Worker = data.Worker.GetID()
+		e.Data = map[string]any{"paused": data.Paused, "reason": data.Reason}
+	case WorkerOnUpdate:
+		ids := make([]string, 0, len(data.Workers))
+		for _, worker := range data.Workers {
+			ids = append(ids, worker.GetID())
+		}
+		e.Data = map[string]any{"workers": ids}
+	case WorkerOnReload:
+		errs := make(map[string]string, len(data.Errors))
+		for file, err := range data.Errors {
+			errs[file] = err.Error()
+		}
+		e.Data = map[string]any{
+			"added":     data.Added,
+			"updated":   data.Updated,
+			"restarted": data.Restarted,
+			"removed":   data.Removed,
+			"errors":    errs,
+		}
+	}
+	return e
+}
+
+// isLogFile reports whether file is one of files
+func isLogFile(files []string, file string) bool {
+	for _, f := range files {
+		if f == file {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,144 @@
+package workman
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-per/simpkg/httpapi"
+	"github.com/go-per/simpkg/identity"
+	"github.com/go-per/simpkg/tasks"
+)
+
+func TestHandler(t *testing.T) {
+	identity.Instance.AddClient("ops")
+	defer identity.Instance.RemoveClient("ops")
+	token, err := identity.Instance.GenerateUiToken("ops")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer identity.Instance.RemoveUiToken(token)
+
+	m := newTestManager(t, "1")
+	w, _ := m.Get("1")
+	w.Logger().Info("booted", nil)
+	server := httptest.NewServer(NewHandler(m))
+	defer server.Close()
+
+	do := func(method, path, body string, auth bool) *http.Response {
+		r, _ := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		if auth {
+			r.Header.Set(httpapi.HeaderUiToken, token)
+		}
+		resp, err := http.DefaultClient.Do(r)
+		if err != nil {
+			t.Fatalf("%v %v error = %v", method, path, err)
+		}
+		return resp
+	}
+
+	// event stream receives worker events
+	events, err := http.Get(server.URL + "/events?token=" + token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer events.Body.Close()
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		auth   bool
+		status int
+	}{
+		{http.MethodGet, "/workers", "", false, http.StatusUnauthorized},
+		{http.MethodGet, "/workers", "", true, http.StatusOK},
+		{http.MethodPost, "/workers", "{", true, http.StatusBadRequest},
+		{http.MethodPost, "/workers", "2", true, http.StatusCreated},
+		{http.MethodPost, "/workers", "2", true, http.StatusBadRequest},
+		{http.MethodPost, "/workers/2/start", "", true, http.StatusOK},
+		{http.MethodPost, "/workers/2/start", "", true, http.StatusConflict},
+		{http.MethodPost, "/workers/2/restart", "", true, http.StatusOK},
+		{http.MethodPost, "/workers/2/stop", "", true, http.StatusOK},
+		{http.MethodGet, "/workers/2/tasks", "", true, http.StatusOK},
+		{http.MethodGet, "/workers/1/logs", "", true, http.StatusOK},
+		{http.MethodGet, "/workers/1/logs?file=../../2/logs/log", "", true, http.StatusBadRequest},
+		{http.MethodGet, "/workers/1/logs?file=missing", "", true, http.StatusBadRequest},
+		{http.MethodDelete, "/workers/2", "", true, http.StatusNoContent},
+		{http.MethodGet, "/workers/2", "", true, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		resp := do(tt.method, tt.path, tt.body, tt.auth)
+		if tt.path == "/workers/1/logs" {
+			var page struct{ Count int }
+			_ = json.NewDecoder(resp.Body).Decode(&page)
+			if page.Count != 1 {
+				t.Errorf("GET %v count = %v, want 1", tt.path, page.Count)
+			}
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.status {
+			t.Errorf("%v %v status = %v, want %v", tt.method, tt.path, resp.StatusCode, tt.status)
+		}
+	}
+
+	scanner := bufio.NewScanner(events.Body)
+	for scanner.Scan() {
+		if line := scanner.Text(); strings.HasPrefix(line, "event: ") {
+			if line != "event: "+string(EventWorkerState) {
+				t.Errorf("first event = %v, want %v", line, EventWorkerState)
+			}
+			break
+		}
+	}
+}
+
+func TestHandler_TasksRunning(t *testing.T) {
+	identity.Instance.AddClient("ops")
+	defer identity.Instance.RemoveClient("ops")
+	token, err := identity.Instance.GenerateUiToken("ops")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer identity.Instance.RemoveUiToken(token)
+
+	m := newTestManager(t, "1")
+	w, _ := m.Get("1")
+	for i, name := range []string{"a", "b", "c"} {
+		w.TaskManager().Add(&tasks.Task{Name: name, Order: i, Handler: func() error { return nil }})
+	}
+	server := httptest.NewServer(NewHandler(m))
+	defer server.Close()
+
+	// current task is read while task manager moves it
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			w.TaskManager().Reset()
+			w.TaskManager().Start()
+		}
+	}()
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+		r, _ := http.NewRequest(http.MethodGet, server.URL+"/workers/1/tasks", nil)
+		r.Header.Set(httpapi.HeaderUiToken, token)
+		resp, err := http.DefaultClient.Do(r)
+		if err != nil {
+			t.Fatalf("GET tasks error = %v", err)
+		}
+		var infos []TaskInfo
+		_ = json.NewDecoder(resp.Body).Decode(&infos)
+		resp.Body.Close()
+		if len(infos) != 3 {
+			t.Fatalf("GET tasks count = %v, want 3", len(infos))
+		}
+	}
+}
@@ -1,15 +1,21 @@
 package workman
 
 import (
+	"context"
 	"errors"
-	"path"
+	"fmt"
+	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/go-per/simpkg/cache"
 	"github.com/go-per/simpkg/client"
+	"github.com/go-per/simpkg/encryption"
 	"github.com/go-per/simpkg/events"
+	"github.com/go-per/simpkg/format"
 	"github.com/go-per/simpkg/helpers"
 	"github.com/go-per/simpkg/logger"
 	"github.com/go-per/simpkg/str"
@@ -22,6 +28,7 @@ type WorkerEvent string
 const (
 	EventWorkerAddRemove WorkerEvent = "worker.add_remove"
 	EventWorkerLoad      WorkerEvent = "workers.load"
+	EventWorkerPause     WorkerEvent = "worker.pause"
 )
 
 // IManager interface
@@ -31,6 +38,12 @@ type IManager interface {
 	SetExtension(ext string)
 	GetExtension() string
 	SetRootPath(path string)
+	SetClientPreset(preset client.Preset)
+	SetCookieEncryptionKey(key []byte)
+	CircuitBreaker() *client.CircuitBreaker
+	TrafficMeter() *client.TrafficMeter
+	SetProxyConfig(config ProxyConfig)
+	WorkerProxy(id string) string
 	SetWorkersDir(path string)
 	GetWorkersPath() string
 	GetWorkerFilePath(id string) string
@@ -40,28 +53,75 @@ type IManager interface {
 	Remove(id string) bool
 	Get(id string) (IWorker, bool)
 	Next() IWorker
+	NextFor(key string) IWorker
+	NextIn(tag string, key ...string) IWorker
+	Filter(tags ...string) []IWorker
+	Groups() map[string]int
+	SetWorkerTags(id string, tags ...string) error
+	StartGroup(tag string) error
+	StopGroup(tag string) error
+	RestartGroup(tag string) error
+	SetSelectionStrategy(strategy SelectionStrategy)
+	SetSupervisorPolicy(policy SupervisorPolicy)
+	StartWorker(id string) error
+	StopWorker(id string) error
+	StartAll()
+	StopAll()
+	WorkerState(id string) (WorkerState, bool)
 	SelectedWorker() IWorker
 	Workers() []IWorker
 	WorkersCount() int
 	Load() error
 	Reload() error
+	WatchWorkers(interval time.Duration)
+	StopWatch()
+	Shutdown(ctx context.Context) ([]WorkerShutdown, error)
+	SetSnapshotInterval(interval time.Duration)
+	StartScheduler(interval time.Duration)
+	StopScheduler()
+	CheckSchedules(now time.Time)
+	SaveSnapshot(id string) error
+	SaveSnapshots() error
+	IsShutdown() bool
 	Initialize(any) error
 	Eventbus() events.IEventbus
 }
 
 // Manager struct
 type Manager struct {
-	isDebug       bool
-	rootPath      string
-	workersDir    string
-	workersExt    string
-	workerBuilder WorkerBuilderFunc
-	workers       []IWorker
-	eventbus      events.IEventbus
-	locker        sync.RWMutex
+	isDebug        bool
+	rootPath       string
+	workersDir     string
+	workersExt     string
+	workerBuilder  WorkerBuilderFunc
+	clientPreset   client.Preset
+	cookieKey      []byte
+	breaker        *client.CircuitBreaker
+	openHosts      map[string]map[string]bool
+	traffic        *client.TrafficMeter
+	proxyConfig    ProxyConfig
+	proxies        map[string]*workerProxy
+	workers        []IWorker
+	adding         map[string]bool // ids reserved by Add until worker is added
+	files          map[string]workerFile
+	watchDone      chan struct{}
+	snapshotDone   chan struct{}
+	scheduleDone   chan struct{}
+	scheduleActive map[string]bool
+	shutdown       bool
+	eventbus       events.IEventbus
+	locker         sync.RWMutex
 
 	selectWorkerLocker sync.RWMutex
 	selectedWorker     IWorker
+	strategy           SelectionStrategy
+	roundRobins        map[string]*roundRobinState
+
+	supervisorLocker sync.Mutex
+	supervised       map[string]*supervised
+	supervisorPolicy SupervisorPolicy
+
+	reloadLocker sync.Mutex
 }
 
 // WorkerBuilderFunc is worker builder function
@@ -74,6 +134,13 @@ type WorkerOnAddRemove struct {
 	Worker  IWorker
 }
 
+// WorkerOnPause struct
+type WorkerOnPause struct {
+	Paused bool
+	Reason string
+	Worker IWorker
+}
+
 // WorkerOnUpdate struct
 type WorkerOnUpdate struct {
 	Workers []IWorker
@@ -81,16 +148,34 @@ type WorkerOnUpdate struct {
 
 // NewManager returns new manager instance
 func NewManager(builder WorkerBuilderFunc) *Manager {
-	return &Manager{
+	m := &Manager{
 		workerBuilder:      builder,
 		workers:            make([]IWorker, 0),
+		adding:             make(map[string]bool),
+		files:              make(map[string]workerFile),
+		proxies:            make(map[string]*workerProxy),
+		scheduleActive:     make(map[string]bool),
 		eventbus:           events.New(),
 		workersDir:         "workers",
 		workersExt:         ".json",
+		clientPreset:       client.PresetBrowser,
+		openHosts:          make(map[string]map[string]bool),
+		traffic:            client.NewTrafficMeter(),
 		locker:             sync.RWMutex{},
 		selectedWorker:     nil,
+		roundRobins:        make(map[string]*roundRobinState),
+		strategy:           SelectRoundRobin,
+		supervised:         make(map[string]*supervised),
+		supervisorPolicy:   DefaultSupervisorPolicy(),
 		selectWorkerLocker: sync.RWMutex{},
 	}
+
+	// pause workers while their upstream circuit is open
+	m.breaker = client.NewCircuitBreaker()
+	m.breaker.SetEventbus(m.eventbus)
+	m.eventbus.Subscribe(client.EventBreakerStateChange, m.onBreakerStateChange)
+
+	return m
 }
 
 // Initialize initializes manager
@@ -126,6 +211,27 @@ func (m *Manager) SetRootPath(path string) {
 	m.rootPath = path
 }
 
+// SetClientPreset sets default client preset of workers
+func (m *Manager) SetClientPreset(preset client.Preset) {
+	m.clientPreset = preset
+}
+
+// SetCookieEncryptionKey sets key which encrypts worker cookies at rest,
+// key must be at least 16 characters
+func (m *Manager) SetCookieEncryptionKey(key []byte) {
+	m.cookieKey = key
+}
+
+// CircuitBreaker returns circuit breaker shared by workers clients
+func (m *Manager) CircuitBreaker() *client.CircuitBreaker {
+	return m.breaker
+}
+
+// TrafficMeter returns traffic meter of workers clients, client key is worker id
+func (m *Manager) TrafficMeter() *client.TrafficMeter {
+	return m.traffic
+}
+
 // RootPath returns root path
 func (m *Manager) RootPath() string {
 	return m.rootPath
@@ -141,6 +247,9 @@ func (m *Manager) Add(index int, data []byte, fileName ...string) (IWorker, erro
 	if m.workerBuilder == nil {
 		return nil, errors.New("worker type is not set")
 	}
+	if m.IsShutdown() {
+		return nil, ErrShutdown
+	}
 
 	// create new worker
 	worker := m.workerBuilder()
@@ -151,61 +260,163 @@ func (m *Manager) Add(index int, data []byte, fileName ...string) (IWorker, erro
 		return nil, err
 	}
 
-	// if already exists
-	if _, ok := m.Get(id); ok {
+	// id is the key of lookups and cache path
+	if strings.TrimSpace(id) == "" {
+		return nil, errors.New("worker id is empty")
+	}
+	if id == "." || id == ".." || strings.ContainsAny(id, `/\`) {
+		return nil, format.Error("worker id is not a valid file name [%v]", id)
+	}
+	if first, second := worker.GetID(), worker.GetID(); first != id || second != id {
+		return nil, format.Error("worker id is not stable, Init returned [%v] and GetID returned [%v]", id, first)
+	}
+
+	// if already exists, id is reserved until worker is added
+	if !m.reserve(id) {
 		return nil, errors.New("worker already exists")
 	}
+	defer m.unreserve(id)
+
+	// configure logger
+	cachePath := m.workerCachePath(worker.GetID())
+	l := logger.New()
+	l.SetRootPath(cachePath)
+
+	// load persistent cookie jar, unreadable cookies are moved aside and worker starts without them
+	var encryptors []encryption.IEncryptor
+	if len(m.cookieKey) > 0 {
+		encryptor := encryption.New()
+		encryptor.SetKey(m.cookieKey)
+		encryptors = append(encryptors, encryptor)
+	}
+	jarPath := filepath.Join(cachePath, "cookies.json")
+	jar, err := client.NewCookieJar(jarPath, encryptors...)
+	if err != nil {
+		l.Error("Could not load worker cookies: %v", nil, err.Error())
+		if err = os.Rename(jarPath, fmt.Sprintf("%s.%d.bad", jarPath, time.Now().Unix())); err != nil {
+			l.Error("Could not move broken worker cookies: %v", nil, err.Error())
+		}
+	}
+
+	// create worker client by preset
+	preset := m.clientPreset
+	if p, ok := worker.(IClientPreset); ok && p.ClientPreset() != "" {
+		preset = p.ClientPreset()
+	}
+	opts := []client.Option{
+		client.WithCookieJar(jar),
+		client.WithRequestLogger(client.NewRequestLogger(l).EnableDump(m.isDebug)),
+		client.WithCircuitBreaker(m.breaker, id),
+		client.WithTrafficMeter(m.traffic, id),
+	}
+	if preset == client.PresetBrowser {
+		opts = append(opts, client.WithProfile(client.ProfileFor(id)))
+	}
+	if opt, ok := m.proxyOption(worker); ok {
+		opts = append(opts, opt)
+	}
+	c, err := client.NewFromPreset(preset, opts...)
+	if err != nil {
+		m.forgetWorkerProxy(id)
+		return nil, err
+	}
 
 	// set worker props
 	worker.SetIndex(index)
 	worker.SetTaskManager(tasks.New())
-	worker.SetClient(client.New())
+	worker.SetClient(c)
 
 	// configure and set cache
-	cachePath := path.Join(m.CachePath(), "_workers", worker.GetID())
 	fileCache := cache.New()
 	fileCache.SetRoot(cachePath)
 	worker.SetCache(fileCache)
 
-	// configure and set logger
-	l := logger.New()
-	l.SetRootPath(cachePath)
+	// set logger
 	worker.SetLogger(l)
 
 	// register listeners
 	worker.RegisterListeners()
 
+	// continue where worker left off, a broken snapshot is skipped
+	if err = m.restoreSnapshot(worker); err != nil {
+		l.Error("Could not restore worker snapshot: %v", nil, err.Error())
+	}
+
 	// initialize worker
+	m.setWorkerState(worker, WorkerBooting, nil)
 	err = worker.Boot()
 	if err != nil {
+		m.setWorkerState(worker, WorkerCrashed, err)
+		m.forgetWorker(worker.GetID())
+		m.forgetWorkerProxy(worker.GetID())
 		return nil, err
 	}
 
 	// add worker to manager
 	m.locker.Lock()
 	m.workers = append(m.workers, worker)
+	if len(fileName) > 0 && fileName[0] != "" {
+		m.files[fileName[0]] = workerFile{id: id, content: data}
+	}
 	m.locker.Unlock()
 
-	// dispatch event
+	// dispatch events
+	m.setWorkerState(worker, WorkerStopped, nil)
 	m.eventbus.Dispatch(string(EventWorkerAddRemove), WorkerOnAddRemove{
 		Added:  true,
 		Worker: worker,
 	})
+	m.dispatchTags(worker, nil, workerTags(worker))
 
 	return worker, nil
 }
 
+// reserve reserves id of a worker being added, reports false when id is taken
+func (m *Manager) reserve(id string) bool {
+	m.locker.Lock()
+	defer m.locker.Unlock()
+
+	if m.adding[id] {
+		return false
+	}
+	for _, wk := range m.workers {
+		if wk.GetID() == id {
+			return false
+		}
+	}
+	m.adding[id] = true
+	return true
+}
+
+// unreserve releases id reserved by Add
+func (m *Manager) unreserve(id string) {
+	m.locker.Lock()
+	delete(m.adding, id)
+	m.locker.Unlock()
+}
+
 // Remove removes worker from manager
 func (m *Manager) Remove(id string) bool {
+	// supervised worker is stopped by supervisor
+	stopped := m.forgetWorker(id)
+
 	m.locker.Lock()
 	removed := false
 
 	var wk IWorker
 	for i, worker := range m.workers {
 		if worker.GetID() == id {
-			worker.Stop()
+			if !stopped {
+				worker.Stop()
+			}
 			m.workers = append(m.workers[:i], m.workers[i+1:]...)
+			delete(m.scheduleActive, id)
 			removed = true
+			for file, loaded := range m.files {
+				if loaded.id == id {
+					delete(m.files, file)
+				}
+			}
 			wk = worker
 			break
 		}
@@ -215,12 +426,16 @@ func (m *Manager) Remove(id string) bool {
 	if !removed {
 		return true
 	}
+	m.forgetWorkerProxy(id)
+	m.pruneSelection(wk)
+	_ = m.saveCookies(wk)
 
 	// dispatch event
 	m.eventbus.Dispatch(string(EventWorkerAddRemove), WorkerOnAddRemove{
 		Removed: true,
 		Worker:  wk,
 	})
+	m.dispatchTags(wk, workerTags(wk), nil)
 
 	return true
 }
@@ -240,51 +455,25 @@ func (m *Manager) Get(id string) (IWorker, bool) {
 	return worker, worker != nil
 }
 
-// Next select workers as circular selection
-func (m *Manager) Next() IWorker {
-	//m.selectWorkerLocker.Lock()
-	//if m.selectedWorker != nil && m.selectedWorker.Index() >= m.WorkersCount() {
-	//	m.selectedWorker = nil
-	//}
-	//
-	//for _, worker := range m.workers {
-	//	if m.selectedWorker == nil || worker.Index() > m.selectedWorker.Index() {
-	//		m.selectedWorker = worker
-	//		break
-	//	}
-	//}
-	//
-	//if m.selectedWorker != nil {
-	//	return m.selectedWorker
-	//}
-	//
-	//if m.WorkersCount() > 0 {
-	//	m.selectedWorker = m.workers[0]
-	//}
-	//m.selectWorkerLocker.Unlock()
-
-	return m.selectedWorker
-}
-
-// SelectedWorker returns selected worker
-func (m *Manager) SelectedWorker() IWorker {
-	return m.selectedWorker
-}
-
 // Workers returns all workers
 func (m *Manager) Workers() []IWorker {
-	return m.workers
+	m.locker.RLock()
+	defer m.locker.RUnlock()
+
+	return append([]IWorker{}, m.workers...)
 }
 
 // WorkersCount returns workers count
 func (m *Manager) WorkersCount() int {
+	m.locker.RLock()
+	defer m.locker.RUnlock()
+
 	return len(m.workers)
 }
 
 // Load loads workers from config files in directory
 func (m *Manager) Load() error {
-	workersPath := filepath.Join(m.rootPath, m.workersDir, "*"+m.workersExt)
-	files, err := filepath.Glob(workersPath)
+	files, err := m.workerFiles()
 	if err != nil {
 		return err
 	}
@@ -309,16 +498,18 @@ func (m *Manager) Load() error {
 	}
 
 	// check for workers count
-	if m.workers == nil || len(m.workers) == 0 {
+	if m.WorkersCount() == 0 {
 		return errors.New("no worker found")
 	}
 
 	// sort workers
+	m.locker.Lock()
 	sort.Slice(m.workers, func(i, j int) bool { return m.workers[i].Index() < m.workers[j].Index() })
+	m.locker.Unlock()
 
 	// dispatch event
 	m.eventbus.Dispatch(string(EventWorkerLoad), WorkerOnUpdate{
-		Workers: m.workers,
+		Workers: m.Workers(),
 	})
 
 	return nil
@@ -334,9 +525,57 @@ func (m *Manager) GetWorkerFilePath(id string) string {
 	return filepath.Join(m.rootPath, m.workersDir, id+m.workersExt)
 }
 
-// Reload workers
-func (m *Manager) Reload() error {
-	return m.Load()
+// onBreakerStateChange pauses workers which used host while circuit is open and resumes them on close
+func (m *Manager) onBreakerStateChange(v ...any) {
+	if len(v) == 0 {
+		return
+	}
+	event, ok := v[0].(client.BreakerEvent)
+	if !ok || event.To == client.BreakerHalfOpen {
+		return
+	}
+
+	paused := event.To == client.BreakerOpen
+	reason := format.Format("circuit %v for %v", event.To, event.Host)
+	for _, id := range event.Owners {
+		worker, ok := m.Get(id)
+		if !ok {
+			continue
+		}
+		pausable, ok := worker.(IPausable)
+		if !ok {
+			continue
+		}
+
+		// worker resumes when all of its hosts are closed
+		m.locker.Lock()
+		hosts, ok := m.openHosts[id]
+		if !ok {
+			hosts = make(map[string]bool)
+			m.openHosts[id] = hosts
+		}
+		if paused {
+			hosts[event.Host] = true
+		} else {
+			delete(hosts, event.Host)
+		}
+		openCount := len(hosts)
+		m.locker.Unlock()
+		if !paused && openCount > 0 {
+			continue
+		}
+
+		if paused {
+			pausable.Pause(reason)
+		} else {
+			pausable.Resume()
+		}
+		m.eventbus.Dispatch(string(EventWorkerPause), WorkerOnPause{
+			Paused: paused,
+			Reason: reason,
+			Worker: worker,
+		})
+	}
 }
 
 // Eventbus returns eventbus instance
@@ -0,0 +1,222 @@
+package workman
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-per/simpkg/client"
+	"github.com/go-per/simpkg/encryption"
+	"github.com/go-per/simpkg/random"
+)
+
+// randomWorker returns a new id on every call
+type randomWorker struct {
+	Worker
+}
+
+func (w *randomWorker) GetID() string {
+	return random.String(8)
+}
+
+func TestConfigID(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		fileName string
+		want     string
+	}{
+		{"string id", `{"id":"alpha"}`, "workers/beta.json", "alpha"},
+		{"numeric id", `{"id":12}`, "", "12"},
+		{"file name", `{"name":"x"}`, "workers/beta.json", "beta"},
+		{"null id", `{"id":null}`, "beta.json", "beta"},
+		{"invalid json", `{`, "beta.json", "beta"},
+		{"empty", `{}`, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ConfigID([]byte(tt.data), tt.fileName); got != tt.want {
+				t.Errorf("ConfigID() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestManager_Add(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder WorkerBuilderFunc
+		data    string
+		file    string
+		wantID  string
+		wantErr bool
+	}{
+		{"config id", func() IWorker { return &Worker{} }, `{"id":"a"}`, "", "a", false},
+		{"file name", func() IWorker { return &Worker{} }, `{}`, "workers/b.json", "b", false},
+		{"empty id", func() IWorker { return &Worker{} }, `{}`, "", "", true},
+		{"path id", func() IWorker { return &Worker{} }, `{"id":"../a"}`, "", "", true},
+		{"unstable id", func() IWorker { return &randomWorker{} }, `{"id":"a"}`, "", "", true},
+		{"unknown preset", func() IWorker { return &Worker{} }, `{"id":"a","preset":"missing"}`, "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewManager(tt.builder)
+			m.SetRootPath(t.TempDir())
+			w, err := m.Add(0, []byte(tt.data), tt.file)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Add() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if m.WorkersCount() != 0 {
+					t.Errorf("WorkersCount() = %v after failed Add(), want 0", m.WorkersCount())
+				}
+				return
+			}
+			if w.GetID() != tt.wantID {
+				t.Errorf("GetID() = %v, want %v", w.GetID(), tt.wantID)
+			}
+			if got, ok := m.Get(tt.wantID); !ok || got != w {
+				t.Errorf("Get(%v) = %v, %v, want added worker", tt.wantID, got, ok)
+			}
+			if got, want := w.Cache().GetRoot(), filepath.Join(m.CachePath(), "_workers", tt.wantID); got != want {
+				t.Errorf("Cache().GetRoot() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestManager_Add_ClientPreset(t *testing.T) {
+	m := NewManager(func() IWorker { return &Worker{} })
+	m.SetRootPath(t.TempDir())
+
+	tests := []struct {
+		data        string
+		wantTimeout time.Duration
+		wantVerify  bool
+	}{
+		{`{"id":"default"}`, time.Minute * 2, false}, // req default timeout
+		{`{"id":"api","preset":"api"}`, time.Second * 30, true},
+		{`{"id":"strict","preset":" strict "}`, time.Second * 30, true},
+	}
+	for i, tt := range tests {
+		w, err := m.Add(i, []byte(tt.data))
+		if err != nil {
+			t.Fatalf("Add(%s) error = %v", tt.data, err)
+		}
+		c := w.Client()
+		if got := c.GetClient().Timeout; got != tt.wantTimeout {
+			t.Errorf("Add(%s) client timeout = %v, want %v", tt.data, got, tt.wantTimeout)
+		}
+		if got := !c.GetTLSClientConfig().InsecureSkipVerify; got != tt.wantVerify {
+			t.Errorf("Add(%s) client verifies tls = %v, want %v", tt.data, got, tt.wantVerify)
+		}
+	}
+}
+
+func TestManager_Add_BrokenCookies(t *testing.T) {
+	m := NewManager(func() IWorker { return &Worker{} })
+	m.SetRootPath(t.TempDir())
+	m.SetCookieEncryptionKey([]byte("0123456789abcdef"))
+
+	jarPath := filepath.Join(m.workerCachePath("a"), "cookies.json")
+	if err := os.MkdirAll(filepath.Dir(jarPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(jarPath, []byte("not encrypted"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	w, err := m.Add(0, []byte(`{"id":"a"}`))
+	if err != nil {
+		t.Fatalf("Add() with broken cookies error = %v", err)
+	}
+	if broken, _ := filepath.Glob(jarPath + ".*.bad"); len(broken) != 1 {
+		t.Errorf("broken cookies moved aside = %v, want 1 file", broken)
+	}
+
+	// pending cookies are saved with snapshot
+	u, _ := url.Parse("https://example.com/")
+	w.Client().GetClient().Jar.SetCookies(u, []*http.Cookie{{Name: "session", Value: "1"}})
+	if err = m.SaveSnapshot("a"); err != nil {
+		t.Fatalf("SaveSnapshot() error = %v", err)
+	}
+	encryptor := encryption.New()
+	encryptor.SetKey([]byte("0123456789abcdef"))
+	jar, err := client.NewCookieJar(jarPath, encryptor)
+	if err != nil {
+		t.Fatalf("NewCookieJar() error = %v", err)
+	}
+	if got := len(jar.Cookies(u)); got != 1 {
+		t.Errorf("saved cookies = %v, want 1", got)
+	}
+}
+
+// slowBootWorker boots slowly, concurrent adds overlap
+type slowBootWorker struct {
+	testWorker
+}
+
+func (w *slowBootWorker) Boot() error {
+	time.Sleep(time.Millisecond * 10)
+	return nil
+}
+
+func TestManager_Add_Concurrent(t *testing.T) {
+	m := NewManager(func() IWorker { return &slowBootWorker{} })
+	m.SetRootPath(t.TempDir())
+
+	var added int32
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := m.Add(i, []byte("a")); err == nil {
+				atomic.AddInt32(&added, 1)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if added != 1 || m.WorkersCount() != 1 {
+		t.Errorf("concurrent Add() added %d workers, WorkersCount() = %d, want 1", added, m.WorkersCount())
+	}
+
+	// id is free again after failed and removed workers
+	m.Remove("a")
+	if _, err := m.Add(0, []byte("a")); err != nil {
+		t.Errorf("Add() after Remove() error = %v", err)
+	}
+}
+
+func TestManager_Remove(t *testing.T) {
+	m := NewManager(func() IWorker { return &Worker{} })
+	m.SetRootPath(t.TempDir())
+	for _, id := range []string{"a", "b", "c"} {
+		if _, err := m.Add(0, []byte(`{"id":"`+id+`"}`)); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+	if _, err := m.Add(0, []byte(`{"id":"b"}`)); err == nil {
+		t.Errorf("Add() of existing id, want error")
+	}
+
+	m.Remove("b")
+	if _, ok := m.Get("b"); ok {
+		t.Errorf("Get() of removed worker, want not found")
+	}
+	var ids []string
+	for _, w := range m.Workers() {
+		ids = append(ids, w.GetID())
+	}
+	if got := joinIDs(ids); got != "a,c" {
+		t.Errorf("Workers() after Remove() = %v, want a,c", got)
+	}
+	if _, err := m.Add(0, []byte(`{"id":"b"}`)); err != nil {
+		t.Errorf("Add() of removed id error = %v", err)
+	}
+}
@@ -0,0 +1,247 @@
+package workman
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/go-per/simpkg/client"
+	"github.com/go-per/simpkg/proxyswitcher"
+	"github.com/imroc/req/v3"
+)
+
+// ProxyPolicy type
+type ProxyPolicy string
+
+const (
+	ProxyNone       ProxyPolicy = ""
+	ProxyDedicated  ProxyPolicy = "dedicated"   // one proxy per worker while it is running
+	ProxyPerRequest ProxyPolicy = "per_request" // a proxy for every request
+	ProxyOnFailure  ProxyPolicy = "on_failure"  // one proxy per worker, rotated on failure or ban
+)
+
+// ErrNoProxy is returned without sending request when switcher has no available proxy
+var ErrNoProxy = errors.New("no proxy is available")
+
+// ProxyConfig struct
+type ProxyConfig struct {
+	Policy   ProxyPolicy
+	Switcher proxyswitcher.ISwitcher
+	Cooldown time.Duration                             // a rotated proxy is reused by the worker after cooldown, zero is never
+	IsBanned func(resp *http.Response, err error) bool // default is error, 403, 407 or 429 status, caller cancellation is not a ban
+}
+
+// IProxyAware is implemented by workers which keep their current proxy, e.g. for details
+type IProxyAware interface {
+	SetProxy(proxy string)
+}
+
+// workerProxy is the proxy state of a worker
+type workerProxy struct {
+	worker IWorker
+	proxy  proxyswitcher.IProxy
+	locker sync.Mutex
+}
+
+// proxyKey is context key of request proxy
+type proxyKey struct{}
+
+// SetProxyConfig sets proxy policy of workers added afterwards
+func (m *Manager) SetProxyConfig(config ProxyConfig) {
+	if config.IsBanned == nil {
+		config.IsBanned = func(resp *http.Response, err error) bool {
+			if err != nil {
+				return !errors.Is(err, context.Canceled)
+			}
+			switch resp.StatusCode {
+			case http.StatusForbidden, http.StatusProxyAuthRequired, http.StatusTooManyRequests:
+				return true
+			}
+			return false
+		}
+	}
+
+	m.locker.Lock()
+	m.proxyConfig = config
+	m.locker.Unlock()
+}
+
+// WorkerProxy returns raw url of the proxy used by worker
+func (m *Manager) WorkerProxy(id string) string {
+	m.locker.RLock()
+	wp, ok := m.proxies[id]
+	m.locker.RUnlock()
+	if !ok {
+		return ""
+	}
+
+	wp.locker.Lock()
+	defer wp.locker.Unlock()
+
+	if wp.proxy == nil {
+		return ""
+	}
+	return wp.proxy.RawUrl()
+}
+
+// proxyOption returns client option of proxy policy
+func (m *Manager) proxyOption(worker IWorker) (client.Option, bool) {
+	m.locker.Lock()
+	config := m.proxyConfig
+	if config.Policy == ProxyNone || config.Switcher == nil {
+		m.locker.Unlock()
+		return nil, false
+	}
+	wp := &workerProxy{worker: worker}
+	m.proxies[worker.GetID()] = wp
+	m.locker.Unlock()
+
+	return client.WithConfigure(func(c *req.Client) {
+		m.attachProxy(c, wp, config)
+	}), true
+}
+
+// attachProxy selects proxy of every request by policy
+func (m *Manager) attachProxy(c *req.Client, wp *workerProxy, config ProxyConfig) {
+	t := c.GetTransport()
+	t.SetProxy(func(r *http.Request) (*url.URL, error) {
+		proxy, ok := r.Context().Value(proxyKey{}).(proxyswitcher.IProxy)
+		if !ok {
+			return nil, nil
+		}
+		u := proxy.Url()
+		return &u, nil
+	})
+
+	id := wp.worker.GetID()
+	t.WrapRoundTripFunc(func(rt http.RoundTripper) req.HttpRoundTripFunc {
+		return func(r *http.Request) (*http.Response, error) {
+			var proxy proxyswitcher.IProxy
+			if config.Policy == ProxyPerRequest {
+				proxy = config.Switcher.Next(id)
+			} else {
+				proxy = wp.acquire(config.Switcher)
+			}
+			if proxy == nil {
+				return nil, ErrNoProxy
+			}
+
+			r = r.WithContext(context.WithValue(r.Context(), proxyKey{}, proxy))
+			resp, err := rt.RoundTrip(r)
+
+			switch {
+			case config.Policy == ProxyPerRequest:
+				release := func() { releaseProxy(proxy, id) }
+				if err != nil {
+					release()
+					break
+				}
+				resp.Body = &proxyBody{ReadCloser: resp.Body, release: release}
+			case config.Policy == ProxyOnFailure && !errors.Is(r.Context().Err(), context.Canceled) && config.IsBanned(resp, err):
+				// requests canceled by caller keep their proxy, timeouts rotate it
+				wp.rotate(proxy, config.Cooldown)
+			}
+			return resp, err
+		}
+	})
+}
+
+// releaseWorkerProxy releases proxy of stopped worker
+func (m *Manager) releaseWorkerProxy(id string) {
+	m.locker.RLock()
+	wp, ok := m.proxies[id]
+	m.locker.RUnlock()
+	if ok {
+		wp.release()
+	}
+}
+
+// forgetWorkerProxy releases proxy of removed worker
+func (m *Manager) forgetWorkerProxy(id string) {
+	m.locker.Lock()
+	wp, ok := m.proxies[id]
+	delete(m.proxies, id)
+	m.locker.Unlock()
+	if ok {
+		wp.release()
+	}
+}
+
+// acquire returns current proxy of worker or takes next one
+func (wp *workerProxy) acquire(switcher proxyswitcher.ISwitcher) proxyswitcher.IProxy {
+	wp.locker.Lock()
+	defer wp.locker.Unlock()
+
+	if wp.proxy == nil {
+		wp.proxy = switcher.Next(wp.worker.GetID())
+		wp.notify()
+	}
+	return wp.proxy
+}
+
+// rotate releases failed proxy, next request takes another one
+func (wp *workerProxy) rotate(proxy proxyswitcher.IProxy, cooldown time.Duration) {
+	wp.locker.Lock()
+	defer wp.locker.Unlock()
+
+	// proxy is already rotated by a concurrent request
+	if wp.proxy != proxy {
+		return
+	}
+	proxy.Release()
+	if cooldown > 0 {
+		proxy.RemoveUsageAfter(wp.worker.GetID(), cooldown)
+	}
+	wp.proxy = nil
+	wp.notify()
+}
+
+// release releases current proxy
+func (wp *workerProxy) release() {
+	wp.locker.Lock()
+	defer wp.locker.Unlock()
+
+	if wp.proxy == nil {
+		return
+	}
+	releaseProxy(wp.proxy, wp.worker.GetID())
+	wp.proxy = nil
+	wp.notify()
+}
+
+// notify sets current proxy of worker, caller must hold the lock
+func (wp *workerProxy) notify() {
+	aware, ok := wp.worker.(IProxyAware)
+	if !ok {
+		return
+	}
+	if wp.proxy == nil {
+		aware.SetProxy("")
+		return
+	}
+	aware.SetProxy(wp.proxy.RawUrl())
+}
+
+// releaseProxy makes proxy available to all usage ids
+func releaseProxy(proxy proxyswitcher.IProxy, usageId string) {
+	proxy.Release()
+	proxy.RemoveUsage(usageId)
+}
+
+// proxyBody releases per request proxy on close
+type proxyBody struct {
+	io.ReadCloser
+	release func()
+	once    sync.Once
+}
+
+// Close closes body and releases proxy
+func (b *proxyBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
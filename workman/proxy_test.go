@@ -0,0 +1,139 @@
+package workman
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-per/simpkg/proxyswitcher"
+)
+
+func TestManager_SetProxyConfig(t *testing.T) {
+	// forward proxies which answer with their name, banned proxy answers 403
+	names := make(map[string]string)
+	newProxy := func(name string, status int) string {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(name))
+		}))
+		t.Cleanup(server.Close)
+		names[server.URL] = name
+		return server.URL
+	}
+	banned, first, second := newProxy("banned", http.StatusForbidden), newProxy("first", http.StatusOK), newProxy("second", http.StatusOK)
+
+	tests := []struct {
+		name    string
+		policy  ProxyPolicy
+		proxies []string
+		want    []string
+		current string
+	}{
+		{"dedicated", ProxyDedicated, []string{first, second}, []string{"first", "first"}, "first"},
+		{"per request", ProxyPerRequest, []string{first, second}, []string{"first", "first"}, ""},
+		{"on failure", ProxyOnFailure, []string{banned, first}, []string{"banned", "first"}, "first"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			switcher := proxyswitcher.New()
+			if err := switcher.Load(tt.proxies); err != nil {
+				t.Fatal(err)
+			}
+			m := NewManager(func() IWorker { return &testWorker{} })
+			m.SetRootPath(t.TempDir())
+			m.SetProxyConfig(ProxyConfig{Policy: tt.policy, Switcher: switcher})
+			w, err := m.Add(0, []byte("a"))
+			if err != nil {
+				t.Fatalf("Add() error = %v", err)
+			}
+
+			for i, want := range tt.want {
+				resp, err := w.Client().R().Get("http://upstream.test/")
+				if err != nil {
+					t.Fatalf("Get() error = %v", err)
+				}
+				if got := resp.String(); got != want {
+					t.Errorf("request %d proxy = %v, want %v", i, got, want)
+				}
+			}
+			if got := names[m.WorkerProxy("a")]; got != tt.current {
+				t.Errorf("WorkerProxy() = %v, want %v", got, tt.current)
+			}
+			if got := names[w.GetDetails().(WorkerDetails).Proxy]; got != tt.current {
+				t.Errorf("GetDetails() proxy = %v, want %v", got, tt.current)
+			}
+
+			// removed worker releases its proxy
+			m.Remove("a")
+			for _, proxy := range switcher.All() {
+				if proxy.IsInUse() {
+					t.Errorf("proxy %v is in use after Remove()", names[proxy.RawUrl()])
+				}
+			}
+		})
+	}
+}
+
+func TestManager_SetProxyConfig_Canceled(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer slow.Close()
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer other.Close()
+
+	switcher := proxyswitcher.New()
+	if err := switcher.Load([]string{slow.URL, other.URL}); err != nil {
+		t.Fatal(err)
+	}
+	m := NewManager(func() IWorker { return &testWorker{} })
+	m.SetRootPath(t.TempDir())
+	m.SetProxyConfig(ProxyConfig{Policy: ProxyOnFailure, Switcher: switcher})
+	w, err := m.Add(0, []byte("a"))
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	// canceled request keeps its proxy
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(time.Millisecond*20, cancel)
+	if _, err = w.Client().R().SetContext(ctx).Get("http://upstream.test/"); err == nil {
+		t.Fatalf("Get() error = nil, want context error")
+	}
+	if got := m.WorkerProxy("a"); got != slow.URL {
+		t.Errorf("WorkerProxy() after cancel = %v, want %v", got, slow.URL)
+	}
+
+	// timed out request rotates hanging proxy
+	ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond*20)
+	defer cancel()
+	if _, err = w.Client().R().SetContext(ctx).Get("http://upstream.test/"); err == nil {
+		t.Fatalf("Get() error = nil, want deadline error")
+	}
+	if _, err = w.Client().R().Get("http://upstream.test/"); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got := m.WorkerProxy("a"); got != other.URL {
+		t.Errorf("WorkerProxy() after timeout = %v, want %v", got, other.URL)
+	}
+
+	m.locker.RLock()
+	isBanned := m.proxyConfig.IsBanned
+	m.locker.RUnlock()
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{context.Canceled, false},
+		{context.DeadlineExceeded, true},
+		{errors.New("proxy connect failed"), true},
+	}
+	for _, tt := range tests {
+		if got := isBanned(nil, tt.err); got != tt.want {
+			t.Errorf("IsBanned(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
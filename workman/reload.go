@@ -0,0 +1,286 @@
+package workman
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-per/simpkg/format"
+	"github.com/go-per/simpkg/helpers"
+)
+
+// EventWorkerReload is dispatched with WorkerOnReload after every reload
+const EventWorkerReload WorkerEvent = "workers.reload"
+
+// IUpdatable is implemented by workers which apply a changed config without restart
+type IUpdatable interface {
+	Update(config []byte) error
+}
+
+// WorkerOnReload struct
+type WorkerOnReload struct {
+	Added     []string
+	Updated   []string
+	Restarted []string
+	Removed   []string
+	Errors    map[string]error // errors by file path
+}
+
+// workerFile is the loaded config file of a worker
+type workerFile struct {
+	id      string
+	content []byte
+}
+
+// Reload diffs workers directory against loaded workers, new files are added, workers of
+// deleted files are stopped and removed, changed workers are updated or restarted and
+// renamed files whose id is unchanged keep their worker
+func (m *Manager) Reload() error {
+	m.reloadLocker.Lock()
+	defer m.reloadLocker.Unlock()
+
+	files, err := m.workerFiles()
+	if err != nil {
+		return err
+	}
+
+	result := WorkerOnReload{Errors: make(map[string]error)}
+	contents := make(map[string][]byte, len(files))
+	for _, file := range files {
+		content, err := helpers.ReadFile(file)
+		if err != nil {
+			result.Errors[file] = err
+			continue
+		}
+		contents[file] = content
+	}
+
+	// files of deleted workers by id, unreadable files are kept
+	m.locker.RLock()
+	deleted := make(map[string]string)
+	for file, loaded := range m.files {
+		if _, ok := contents[file]; !ok && result.Errors[file] == nil {
+			deleted[loaded.id] = file
+		}
+	}
+	m.locker.RUnlock()
+
+	// renamed files keep their worker
+	for _, file := range files {
+		content, ok := contents[file]
+		if !ok {
+			continue
+		}
+		m.locker.RLock()
+		_, loaded := m.files[file]
+		m.locker.RUnlock()
+		if loaded {
+			continue
+		}
+
+		if id, err := m.workerBuilder().Init(content, file); err == nil && deleted[id] != "" {
+			m.locker.Lock()
+			m.files[file] = m.files[deleted[id]]
+			delete(m.files, deleted[id])
+			m.locker.Unlock()
+			delete(deleted, id)
+		}
+	}
+
+	// deleted files are removed before new files are added
+	for id := range deleted {
+		m.Remove(id)
+		result.Removed = append(result.Removed, id)
+	}
+	sort.Strings(result.Removed)
+
+	for i, file := range files {
+		content, ok := contents[file]
+		if !ok {
+			continue
+		}
+
+		m.locker.RLock()
+		loaded, ok := m.files[file]
+		m.locker.RUnlock()
+
+		// new file
+		if !ok {
+			worker, err := m.Add(i, content, file)
+			if err != nil {
+				result.Errors[file] = err
+				continue
+			}
+			result.Added = append(result.Added, worker.GetID())
+			continue
+		}
+
+		if worker, ok := m.Get(loaded.id); ok {
+			worker.SetIndex(i)
+		}
+		if bytes.Equal(loaded.content, content) {
+			continue
+		}
+
+		// changed file
+		restarted, err := m.updateWorker(i, loaded.id, content, file)
+		if err != nil {
+			result.Errors[file] = err
+			continue
+		}
+		if restarted {
+			result.Restarted = append(result.Restarted, loaded.id)
+		} else {
+			result.Updated = append(result.Updated, loaded.id)
+		}
+	}
+
+	// sort workers
+	m.locker.Lock()
+	sort.SliceStable(m.workers, func(i, j int) bool { return m.workers[i].Index() < m.workers[j].Index() })
+	m.locker.Unlock()
+
+	// dispatch event
+	m.eventbus.Dispatch(string(EventWorkerReload), result)
+
+	if len(result.Errors) > 0 {
+		msgs := make([]string, 0, len(result.Errors))
+		for file, err := range result.Errors {
+			msgs = append(msgs, filepath.Base(file)+": "+err.Error())
+		}
+		sort.Strings(msgs)
+		return errors.New("reload failed: " + strings.Join(msgs, "; "))
+	}
+	return nil
+}
+
+// WatchWorkers reloads workers when files of workers directory change, directory is polled
+// every interval, calling it again replaces the previous watcher
+func (m *Manager) WatchWorkers(interval time.Duration) {
+	m.StopWatch()
+
+	done := make(chan struct{})
+	m.locker.Lock()
+	m.watchDone = done
+	m.locker.Unlock()
+
+	go func() {
+		last := m.snapshot()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				current := m.snapshot()
+				if current == last {
+					continue
+				}
+				last = current
+				// errors are reported by reload event
+				_ = m.Reload()
+			}
+		}
+	}()
+}
+
+// StopWatch stops workers directory watcher
+func (m *Manager) StopWatch() {
+	m.locker.Lock()
+	done := m.watchDone
+	m.watchDone = nil
+	m.locker.Unlock()
+
+	if done != nil {
+		close(done)
+	}
+}
+
+// updateWorker applies changed config, workers without Update and workers whose id
+// changed are replaced, started workers are started again
+func (m *Manager) updateWorker(index int, id string, content []byte, file string) (bool, error) {
+	worker, ok := m.Get(id)
+	if !ok {
+		return false, format.Error("worker not found [%v]", id)
+	}
+
+	if updatable, ok := worker.(IUpdatable); ok {
+		if newID, err := m.workerBuilder().Init(content, file); err == nil && newID == id {
+			before := workerTags(worker)
+			if err := updatable.Update(content); err != nil {
+				return false, err
+			}
+			m.dispatchTags(worker, before, workerTags(worker))
+			m.locker.Lock()
+			m.files[file] = workerFile{id: id, content: content}
+			m.locker.Unlock()
+			return false, nil
+		}
+	}
+
+	// invalid configs keep the running worker
+	if _, err := m.workerBuilder().Init(content, file); err != nil {
+		return false, err
+	}
+
+	m.locker.RLock()
+	previous := m.files[file]
+	m.locker.RUnlock()
+	state, _ := m.WorkerState(id)
+	started := state == WorkerRunning || state == WorkerBackoff
+
+	// store state of stopped worker like Shutdown, the new worker continues from it
+	if started {
+		_ = m.StopWorker(id)
+	}
+	if err := m.saveSnapshot(worker); err != nil {
+		if started {
+			_ = m.StartWorker(id)
+		}
+		return false, err
+	}
+
+	m.Remove(id)
+	worker, err := m.Add(index, content, file)
+	if err != nil {
+		// restore previous worker
+		if worker, restoreErr := m.Add(index, previous.content, file); restoreErr == nil && started {
+			_ = m.StartWorker(worker.GetID())
+		}
+		return false, err
+	}
+	if started {
+		return true, m.StartWorker(worker.GetID())
+	}
+	return true, nil
+}
+
+// workerFiles returns config files of workers directory
+func (m *Manager) workerFiles() ([]string, error) {
+	return filepath.Glob(filepath.Join(m.rootPath, m.workersDir, "*"+m.workersExt))
+}
+
+// snapshot returns names, sizes and modification times of worker files
+func (m *Manager) snapshot() string {
+	files, _ := m.workerFiles()
+
+	var b strings.Builder
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		b.WriteString(file)
+		b.WriteString(info.ModTime().String())
+		b.WriteString(strconv.FormatInt(info.Size(), 10))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
@@ -0,0 +1,242 @@
+package workman
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// configWorker is a worker with "id=version" config
+type configWorker struct {
+	Worker
+	id      string
+	version string
+}
+
+func (w *configWorker) Init(data []byte, _ ...string) (string, error) {
+	var ok bool
+	if w.id, w.version, ok = strings.Cut(string(data), "="); !ok {
+		return "", errors.New("invalid config")
+	}
+	return w.id, nil
+}
+
+func (w *configWorker) GetID() string {
+	return w.id
+}
+
+// updatableWorker applies config changes without restart
+type updatableWorker struct {
+	configWorker
+}
+
+func (w *updatableWorker) Update(config []byte) error {
+	_, w.version, _ = strings.Cut(string(config), "=")
+	return nil
+}
+
+// snapshotConfigWorker keeps a counter across restarts
+type snapshotConfigWorker struct {
+	configWorker
+	counter string
+}
+
+func (w *snapshotConfigWorker) Snapshot() ([]byte, error) {
+	return []byte(w.counter), nil
+}
+
+func (w *snapshotConfigWorker) Restore(data []byte) error {
+	w.counter = string(data)
+	return nil
+}
+
+func TestManager_Reload(t *testing.T) {
+	m := NewManager(func() IWorker {
+		return &updatableWorker{}
+	})
+	root := t.TempDir()
+	m.SetRootPath(root)
+	dir := m.GetWorkersPath()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name+".json"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("a", "a=1")
+	write("b", "b=1")
+	if err := m.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	a, _ := m.Get("a")
+
+	var result WorkerOnReload
+	m.Eventbus().Subscribe(string(EventWorkerReload), func(v ...any) { result = v[0].(WorkerOnReload) })
+
+	write("a", "a=2")
+	write("c", "c=1")
+	if err := os.Remove(filepath.Join(dir, "b.json")); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	if got := joinIDs(result.Added) + "|" + joinIDs(result.Updated) + "|" + joinIDs(result.Removed); got != "c|a|b" {
+		t.Errorf("Reload() added|updated|removed = %v, want c|a|b", got)
+	}
+	if w, _ := m.Get("a"); w != a || w.(*updatableWorker).version != "2" {
+		t.Errorf("Reload() did not update worker in place")
+	}
+	if _, ok := m.Get("b"); ok {
+		t.Errorf("Reload() did not remove worker b")
+	}
+
+	// unchanged directory is a no-op
+	if err := m.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if got := len(result.Added) + len(result.Updated) + len(result.Removed); got != 0 || m.WorkersCount() != 2 {
+		t.Errorf("Reload() of unchanged directory changed %d workers", got)
+	}
+}
+
+func TestManager_Reload_Broken(t *testing.T) {
+	m := NewManager(func() IWorker {
+		return &configWorker{}
+	})
+	m.SetRootPath(t.TempDir())
+	dir := m.GetWorkersPath()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "a.json")
+	if err := os.WriteFile(file, []byte("a=1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if err := m.StartWorker("a"); err != nil {
+		t.Fatalf("StartWorker() error = %v", err)
+	}
+	a, _ := m.Get("a")
+
+	// broken edit keeps the running worker
+	if err := os.WriteFile(file, []byte("broken"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Reload(); err == nil {
+		t.Errorf("Reload() of broken config, want error")
+	}
+	if w, _ := m.Get("a"); w != a {
+		t.Errorf("Reload() of broken config replaced worker")
+	}
+	if state := waitState(m, "a", WorkerRunning); state != WorkerRunning {
+		t.Errorf("WorkerState(a) = %v, want %v", state, WorkerRunning)
+	}
+}
+
+func TestManager_Reload_Rename(t *testing.T) {
+	m := NewManager(func() IWorker {
+		return &configWorker{}
+	})
+	m.SetRootPath(t.TempDir())
+	dir := m.GetWorkersPath()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a.json"), []byte("a=1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if err := m.StartWorker("a"); err != nil {
+		t.Fatalf("StartWorker() error = %v", err)
+	}
+	a, _ := m.Get("a")
+
+	var result WorkerOnReload
+	m.Eventbus().Subscribe(string(EventWorkerReload), func(v ...any) { result = v[0].(WorkerOnReload) })
+
+	renamed := filepath.Join(dir, "renamed.json")
+	if err := os.Rename(filepath.Join(dir, "a.json"), renamed); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Reload(); err != nil {
+		t.Fatalf("Reload() of renamed file error = %v", err)
+	}
+	if got := len(result.Added) + len(result.Removed) + len(result.Restarted); got != 0 {
+		t.Errorf("Reload() of renamed file changed %d workers", got)
+	}
+	if w, _ := m.Get("a"); w != a {
+		t.Errorf("Reload() of renamed file replaced worker")
+	}
+	if state := waitState(m, "a", WorkerRunning); state != WorkerRunning {
+		t.Errorf("WorkerState(a) = %v, want %v", state, WorkerRunning)
+	}
+
+	// worker follows its new file
+	if err := os.WriteFile(renamed, []byte("a=2"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if w, _ := m.Get("a"); w == a || w.(*configWorker).version != "2" {
+		t.Errorf("Reload() did not replace worker of renamed file")
+	}
+	if state := waitState(m, "a", WorkerRunning); state != WorkerRunning {
+		t.Errorf("WorkerState(a) = %v, want %v after restart", state, WorkerRunning)
+	}
+}
+
+func TestManager_Reload_Snapshot(t *testing.T) {
+	m := NewManager(func() IWorker {
+		return &snapshotConfigWorker{}
+	})
+	m.SetRootPath(t.TempDir())
+	dir := m.GetWorkersPath()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "a.json")
+	if err := os.WriteFile(file, []byte("a=1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if err := m.StartWorker("a"); err != nil {
+		t.Fatalf("StartWorker() error = %v", err)
+	}
+	if state := waitState(m, "a", WorkerRunning); state != WorkerRunning {
+		t.Fatalf("WorkerState(a) = %v, want %v", state, WorkerRunning)
+	}
+	a, _ := m.Get("a")
+	a.(*snapshotConfigWorker).counter = "42"
+
+	// restarted worker continues from state of the previous one
+	if err := os.WriteFile(file, []byte("a=2"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	w, _ := m.Get("a")
+	if w == a {
+		t.Fatalf("Reload() did not restart worker")
+	}
+	if got := w.(*snapshotConfigWorker).counter; got != "42" {
+		t.Errorf("counter after Reload() = %q, want %q", got, "42")
+	}
+	if state := waitState(m, "a", WorkerRunning); state != WorkerRunning {
+		t.Errorf("WorkerState(a) = %v, want %v", state, WorkerRunning)
+	}
+}
@@ -0,0 +1,100 @@
+package workman
+
+import (
+	"time"
+
+	"github.com/go-per/simpkg/timerange"
+)
+
+// EventWorkerSchedule is dispatched with WorkerOnSchedule when schedule window of worker opens or closes
+const EventWorkerSchedule WorkerEvent = "worker.schedule"
+
+// IScheduled is implemented by workers which run only inside schedule windows
+type IScheduled interface {
+	Schedule() *timerange.Schedule
+}
+
+// WorkerOnSchedule struct
+type WorkerOnSchedule struct {
+	Active bool // window is open
+	Err    error
+	Worker IWorker
+}
+
+// StartScheduler starts and stops scheduled workers when their windows open and close, schedules
+// are checked every interval, a worker stopped by hand inside its window is started in the next window
+func (m *Manager) StartScheduler(interval time.Duration) {
+	m.StopScheduler()
+
+	done := make(chan struct{})
+	m.locker.Lock()
+	m.scheduleDone = done
+	m.locker.Unlock()
+
+	go func() {
+		m.CheckSchedules(time.Now())
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				m.CheckSchedules(now)
+			}
+		}
+	}()
+}
+
+// StopScheduler stops scheduler, running workers keep running
+func (m *Manager) StopScheduler() {
+	m.locker.Lock()
+	done := m.scheduleDone
+	m.scheduleDone = nil
+	m.locker.Unlock()
+
+	if done != nil {
+		close(done)
+	}
+}
+
+// CheckSchedules starts workers whose window opened and stops workers whose window closed at now
+func (m *Manager) CheckSchedules(now time.Time) {
+	for _, worker := range m.Workers() {
+		scheduled, ok := worker.(IScheduled)
+		if !ok || scheduled.Schedule() == nil || scheduled.Schedule().IsEmpty() {
+			continue
+		}
+
+		id := worker.GetID()
+		active := scheduled.Schedule().Active(now)
+
+		m.locker.Lock()
+		previous, known := m.scheduleActive[id]
+		m.scheduleActive[id] = active
+		m.locker.Unlock()
+		if known && previous == active {
+			continue
+		}
+
+		// first check only changes workers which are not in their scheduled state
+		state, _ := m.WorkerState(id)
+		started := state == WorkerRunning || state == WorkerBackoff
+		var err error
+		switch {
+		case active && !started:
+			err = m.StartWorker(id)
+		case !active && started:
+			err = m.StopWorker(id)
+		case !known:
+			continue
+		}
+
+		m.eventbus.Dispatch(string(EventWorkerSchedule), WorkerOnSchedule{
+			Active: active,
+			Err:    err,
+			Worker: worker,
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package workman
+
+import (
+	"testing"
+	"time"
+)
+
+func TestManager_CheckSchedules(t *testing.T) {
+	m := NewManager(func() IWorker { return &Worker{} })
+	m.SetRootPath(t.TempDir())
+	for i, config := range []string{
+		`{"id":"office","schedule":{"timezone":"UTC","windows":[{"days":["mon-fri"],"start":"09:00","end":"17:00"}]}}`,
+		`{"id":"night","schedule":{"timezone":"UTC","windows":[{"start":"22:00","end":"06:00"}]}}`,
+		`{"id":"free"}`,
+	} {
+		if _, err := m.Add(i, []byte(config)); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+	if _, err := m.Add(3, []byte(`{"id":"bad","schedule":{"windows":[{"days":["someday"],"start":"09:00","end":"17:00"}]}}`)); err == nil {
+		t.Errorf("Add() with invalid schedule, want error")
+	}
+
+	var events []WorkerOnSchedule
+	m.Eventbus().Subscribe(string(EventWorkerSchedule), func(v ...any) { events = append(events, v[0].(WorkerOnSchedule)) })
+
+	tests := []struct {
+		name   string
+		now    time.Time
+		want   map[string]WorkerState
+		events int
+	}{
+		{
+			name:   "monday morning",
+			now:    time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC),
+			want:   map[string]WorkerState{"office": WorkerRunning, "night": WorkerStopped, "free": WorkerStopped},
+			events: 1,
+		},
+		{
+			name:   "same window",
+			now:    time.Date(2026, 10, 19, 16, 59, 0, 0, time.UTC),
+			want:   map[string]WorkerState{"office": WorkerRunning, "night": WorkerStopped, "free": WorkerStopped},
+			events: 0,
+		},
+		{
+			name:   "after midnight",
+			now:    time.Date(2026, 10, 20, 1, 0, 0, 0, time.UTC),
+			want:   map[string]WorkerState{"office": WorkerStopped, "night": WorkerRunning, "free": WorkerStopped},
+			events: 2,
+		},
+		{
+			name:   "saturday noon",
+			now:    time.Date(2026, 10, 24, 12, 0, 0, 0, time.UTC),
+			want:   map[string]WorkerState{"office": WorkerStopped, "night": WorkerStopped, "free": WorkerStopped},
+			events: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events = nil
+			m.CheckSchedules(tt.now)
+			for id, want := range tt.want {
+				deadline := time.Now().Add(time.Second)
+				state, _ := m.WorkerState(id)
+				for state != want && time.Now().Before(deadline) {
+					time.Sleep(time.Millisecond)
+					state, _ = m.WorkerState(id)
+				}
+				if state != want {
+					t.Errorf("WorkerState(%v) = %v, want %v", id, state, want)
+				}
+			}
+			if len(events) != tt.events {
+				t.Errorf("schedule events = %d, want %d", len(events), tt.events)
+			}
+			for _, e := range events {
+				if e.Err != nil {
+					t.Errorf("schedule event of %v error = %v", e.Worker.GetID(), e.Err)
+				}
+			}
+		})
+	}
+}
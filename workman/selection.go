@@ -0,0 +1,240 @@
+package workman
+
+import (
+	"hash/fnv"
+	"math/rand"
+
+	"github.com/go-per/simpkg/tasks"
+)
+
+// SelectionStrategy type
+type SelectionStrategy string
+
+const (
+	SelectRoundRobin SelectionStrategy = "round_robin"
+	SelectLeastBusy  SelectionStrategy = "least_busy"
+	SelectRandom     SelectionStrategy = "random"
+	SelectWeighted   SelectionStrategy = "weighted"
+	SelectSticky     SelectionStrategy = "sticky"
+)
+
+// IWeighted is implemented by workers which have a selection weight, e.g. from worker config
+type IWeighted interface {
+	Weight() int
+}
+
+// ILoad is implemented by workers which report their own load
+type ILoad interface {
+	Load() int
+}
+
+// SetSelectionStrategy sets strategy of Next
+func (m *Manager) SetSelectionStrategy(strategy SelectionStrategy) {
+	m.selectWorkerLocker.Lock()
+	m.strategy = strategy
+	m.selectWorkerLocker.Unlock()
+}
+
+// SelectionStrategy returns strategy of Next
+func (m *Manager) SelectionStrategy() SelectionStrategy {
+	m.selectWorkerLocker.RLock()
+	defer m.selectWorkerLocker.RUnlock()
+
+	return m.strategy
+}
+
+// roundRobinState is round-robin position of a group
+type roundRobinState struct {
+	last     IWorker
+	position int
+}
+
+// Next selects next available worker by selection strategy, paused workers are skipped
+func (m *Manager) Next() IWorker {
+	return m.NextFor("")
+}
+
+// NextFor selects worker by strategy, sticky strategy returns the same worker for the same key
+// while it is available, without key sticky falls back to round-robin
+func (m *Manager) NextFor(key string) IWorker {
+	return m.NextIn("", key)
+}
+
+// NextIn selects worker of group by strategy, empty tag selects from all workers,
+// groups keep their own round-robin position
+func (m *Manager) NextIn(tag string, key ...string) IWorker {
+	workers := m.availableWorkers(tag)
+
+	m.selectWorkerLocker.Lock()
+	defer m.selectWorkerLocker.Unlock()
+
+	if len(workers) == 0 {
+		m.selectedWorker = nil
+		return nil
+	}
+
+	var worker IWorker
+	switch m.strategy {
+	case SelectLeastBusy:
+		worker = leastBusy(workers)
+	case SelectRandom:
+		worker = workers[rand.Intn(len(workers))]
+	case SelectWeighted:
+		worker = weighted(workers)
+	case SelectSticky:
+		if len(key) > 0 && key[0] != "" {
+			worker = sticky(workers, key[0])
+			break
+		}
+		fallthrough
+	default:
+		worker = m.roundRobin(tag, workers)
+	}
+
+	m.selectedWorker = worker
+	return worker
+}
+
+// SelectedWorker returns last selected worker
+func (m *Manager) SelectedWorker() IWorker {
+	m.selectWorkerLocker.RLock()
+	defer m.selectWorkerLocker.RUnlock()
+
+	return m.selectedWorker
+}
+
+// availableWorkers returns workers of group which are not paused
+func (m *Manager) availableWorkers(tag string) []IWorker {
+	m.locker.RLock()
+	defer m.locker.RUnlock()
+
+	workers := make([]IWorker, 0, len(m.workers))
+	for _, worker := range m.workers {
+		if tag != "" && !hasTag(worker, tag) {
+			continue
+		}
+		if pausable, ok := worker.(IPausable); ok && pausable.IsPaused() {
+			continue
+		}
+		workers = append(workers, worker)
+	}
+	return workers
+}
+
+// pruneSelection drops selection references to removed worker and round-robin state of
+// groups without workers, nil worker only drops empty groups
+func (m *Manager) pruneSelection(removed IWorker) {
+	groups := m.Groups()
+
+	m.selectWorkerLocker.Lock()
+	defer m.selectWorkerLocker.Unlock()
+
+	if removed != nil && m.selectedWorker == removed {
+		m.selectedWorker = nil
+	}
+	for tag, state := range m.roundRobins {
+		if tag != "" && groups[tag] == 0 {
+			delete(m.roundRobins, tag)
+			continue
+		}
+		// position of removed worker is taken by the next one
+		if removed != nil && state.last == removed {
+			state.last = nil
+		}
+	}
+}
+
+// roundRobin returns worker of group after the last selected one, when the last one is removed
+// rotation continues from its position, caller must hold the select lock
+func (m *Manager) roundRobin(tag string, workers []IWorker) IWorker {
+	state, ok := m.roundRobins[tag]
+	if !ok {
+		state = &roundRobinState{}
+		m.roundRobins[tag] = state
+	}
+
+	// the worker after a removed one takes its position
+	next := state.position
+	if state.last != nil {
+		for i, worker := range workers {
+			if worker == state.last {
+				next = i + 1
+				break
+			}
+		}
+	}
+	next %= len(workers)
+
+	state.last, state.position = workers[next], next
+	return workers[next]
+}
+
+// leastBusy returns worker with the lowest load, ties are broken by order
+func leastBusy(workers []IWorker) IWorker {
+	selected, minLoad := workers[0], workerLoad(workers[0])
+	for _, worker := range workers[1:] {
+		if load := workerLoad(worker); load < minLoad {
+			selected, minLoad = worker, load
+		}
+	}
+	return selected
+}
+
+// workerLoad returns reported load or count of running tasks
+func workerLoad(worker IWorker) int {
+	if l, ok := worker.(ILoad); ok {
+		return l.Load()
+	}
+
+	tm := worker.TaskManager()
+	if tm == nil || !tm.IsStarted() {
+		return 0
+	}
+
+	load := 0
+	for _, task := range tm.Items() {
+		switch task.Status() {
+		case tasks.StatusStart, tasks.StatusRetry, tasks.StatusPending:
+			load++
+		}
+	}
+	return load
+}
+
+// weighted returns random worker by weight
+func weighted(workers []IWorker) IWorker {
+	total := 0
+	for _, worker := range workers {
+		total += workerWeight(worker)
+	}
+
+	n := rand.Intn(total)
+	for _, worker := range workers {
+		if n -= workerWeight(worker); n < 0 {
+			return worker
+		}
+	}
+	return workers[len(workers)-1]
+}
+
+// workerWeight returns worker weight, at least 1
+func workerWeight(worker IWorker) int {
+	if w, ok := worker.(IWeighted); ok && w.Weight() > 0 {
+		return w.Weight()
+	}
+	return 1
+}
+
+// sticky returns worker by rendezvous hashing, only keys of a removed worker move
+func sticky(workers []IWorker, key string) IWorker {
+	var selected IWorker
+	var maxScore uint64
+	for _, worker := range workers {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(key + "\x00" + worker.GetID()))
+		if score := h.Sum64(); selected == nil || score > maxScore {
+			selected, maxScore = worker, score
+		}
+	}
+	return selected
+}
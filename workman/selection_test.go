@@ -0,0 +1,138 @@
+package workman
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// testWorker is a worker with config id and weight
+type testWorker struct {
+	Worker
+	id string
+}
+
+func (w *testWorker) Init(data []byte, _ ...string) (string, error) {
+	w.id = string(data)
+	w.SetWeight(len(w.id))
+	return w.id, nil
+}
+
+func (w *testWorker) GetID() string {
+	return w.id
+}
+
+func newTestManager(t *testing.T, ids ...string) *Manager {
+	m := NewManager(func() IWorker { return &testWorker{} })
+	m.SetRootPath(t.TempDir())
+	for i, id := range ids {
+		if _, err := m.Add(i, []byte(id)); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+	return m
+}
+
+func TestManager_Next(t *testing.T) {
+	m := newTestManager(t, "a", "b", "c")
+
+	var got []string
+	for i := 0; i < 4; i++ {
+		got = append(got, m.Next().GetID())
+	}
+	if want := "a,b,c,a"; joinIDs(got) != want {
+		t.Errorf("Next() = %v, want %v", joinIDs(got), want)
+	}
+
+	// removed worker continues rotation from its position
+	m.Remove("a")
+	if id := m.Next().GetID(); id != "b" {
+		t.Errorf("Next() after Remove() = %v, want b", id)
+	}
+
+	// paused workers are skipped
+	w, _ := m.Get("c")
+	w.(IPausable).Pause("test")
+	if id := m.Next().GetID(); id != "b" {
+		t.Errorf("Next() with paused worker = %v, want b", id)
+	}
+}
+
+func TestManager_NextFor(t *testing.T) {
+	m := newTestManager(t, "a", "bb", "ccc", "dddd")
+	m.SetSelectionStrategy(SelectSticky)
+
+	first := make(map[string]string)
+	for i := 0; i < 50; i++ {
+		key := strconv.Itoa(i)
+		first[key] = m.NextFor(key).GetID()
+	}
+
+	// concurrent callers and a removed worker only move keys of that worker
+	m.Remove("bb")
+	var wg sync.WaitGroup
+	for key, id := range first {
+		wg.Add(1)
+		go func(key, id string) {
+			defer wg.Done()
+			if got := m.NextFor(key).GetID(); id != "bb" && got != id {
+				t.Errorf("NextFor(%v) = %v, want %v", key, got, id)
+			}
+		}(key, id)
+	}
+	wg.Wait()
+
+	m.SetSelectionStrategy(SelectWeighted)
+	counts := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		counts[m.Next().GetID()]++
+	}
+	if counts["dddd"] <= counts["a"] {
+		t.Errorf("weighted counts = %v", counts)
+	}
+}
+
+func TestManager_NextWeighted_Config(t *testing.T) {
+	m := NewManager(func() IWorker { return &Worker{} })
+	m.SetRootPath(t.TempDir())
+	m.SetSelectionStrategy(SelectWeighted)
+	for i, config := range []string{`{"id":"a"}`, `{"id":"b","weight":9}`} {
+		if _, err := m.Add(i, []byte(config)); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+	for i, config := range []string{`{"id":"c","weight":-1}`, `{"id":"d","weight":"heavy"}`} {
+		if _, err := m.Add(i+2, []byte(config)); err == nil {
+			t.Errorf("Add(%s) with invalid weight, want error", config)
+		}
+	}
+
+	// weight is changed while workers are selected
+	w, _ := m.Get("a")
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			w.(*Worker).SetWeight(1)
+		}
+	}()
+	counts := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		counts[m.Next().GetID()]++
+	}
+	wg.Wait()
+	if counts["b"] < counts["a"]*3 {
+		t.Errorf("weighted counts = %v, want b about 9 times a", counts)
+	}
+}
+
+func joinIDs(ids []string) (s string) {
+	for i, id := range ids {
+		if i > 0 {
+			s += ","
+		}
+		s += id
+	}
+	return
+}
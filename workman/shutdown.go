@@ -0,0 +1,139 @@
+package workman
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrShutdown is returned when workers are added or started after Shutdown
+var ErrShutdown = errors.New("manager is shut down")
+
+// IDrainer is implemented by workers which reach a safe point themselves, Drain must return
+// when running work can be stopped or ctx is done
+type IDrainer interface {
+	Drain(ctx context.Context) error
+}
+
+// IFlusher is implemented by caches and loggers which write asynchronously
+type IFlusher interface {
+	Flush(ctx context.Context) error
+}
+
+// WorkerShutdown is shutdown report of a worker
+type WorkerShutdown struct {
+	ID       string        `json:"id"`
+	Drained  bool          `json:"drained"` // running tasks finished before stop
+	Forced   bool          `json:"forced"`  // stopped after ctx deadline
+	Duration time.Duration `json:"duration"`
+	Err      error         `json:"-"`
+}
+
+// Shutdown stops new task starts, waits for running tasks of every worker, stops workers,
+// stores their snapshots and flushes caches and loggers, workers which are not drained when
+// ctx is done are force stopped
+func (m *Manager) Shutdown(ctx context.Context) ([]WorkerShutdown, error) {
+	m.locker.Lock()
+	m.shutdown = true
+	m.locker.Unlock()
+	m.StopWatch()
+	m.StopScheduler()
+	m.SetSnapshotInterval(0)
+
+	workers := m.Workers()
+	reports := make([]WorkerShutdown, len(workers))
+
+	var wg sync.WaitGroup
+	for i, worker := range workers {
+		wg.Add(1)
+		go func(i int, worker IWorker) {
+			defer wg.Done()
+			reports[i] = m.shutdownWorker(ctx, worker)
+		}(i, worker)
+	}
+	wg.Wait()
+
+	return reports, ctx.Err()
+}
+
+// IsShutdown reports whether Shutdown is called
+func (m *Manager) IsShutdown() bool {
+	m.locker.RLock()
+	defer m.locker.RUnlock()
+
+	return m.shutdown
+}
+
+// shutdownWorker drains, flushes and stops worker
+func (m *Manager) shutdownWorker(ctx context.Context, worker IWorker) WorkerShutdown {
+	started := time.Now()
+	report := WorkerShutdown{ID: worker.GetID()}
+
+	// stop new task starts, running task finishes
+	if tm := worker.TaskManager(); tm != nil {
+		tm.Stop()
+	}
+
+	// wait for safe point
+	var err error
+	if drainer, ok := worker.(IDrainer); ok {
+		err = safeCall(func() error { return drainer.Drain(ctx) })
+	} else {
+		err = m.waitIdle(ctx, worker.GetID())
+	}
+	report.Drained = err == nil
+	report.Forced = err != nil && ctx.Err() != nil
+	report.Err = err
+
+	// stop supervised worker or worker started by caller, a stuck worker is left behind
+	if err := m.stopWorker(ctx, worker.GetID()); err != nil && !errors.Is(err, ctx.Err()) {
+		if stopErr := safeCall(func() error { worker.Stop(); return nil }); report.Err == nil {
+			report.Err = stopErr
+		}
+	}
+
+	// store state to resume after restart
+	if err := m.saveSnapshot(worker); err != nil && report.Err == nil {
+		report.Err = err
+	}
+
+	// flush async writes
+	for _, v := range []any{worker.Cache(), worker.Logger()} {
+		if flusher, ok := v.(IFlusher); ok {
+			if flushErr := flusher.Flush(ctx); report.Err == nil {
+				report.Err = flushErr
+			}
+		}
+	}
+
+	report.Duration = time.Since(started)
+	return report
+}
+
+// isBusy reports whether a task of worker is running
+func (m *Manager) isBusy(id string) bool {
+	m.supervisorLocker.Lock()
+	defer m.supervisorLocker.Unlock()
+
+	s, ok := m.supervised[id]
+	return ok && len(s.running) > 0
+}
+
+// waitIdle waits until no task of worker is running
+func (m *Manager) waitIdle(ctx context.Context, id string) error {
+	ticker := time.NewTicker(time.Millisecond * 10)
+	defer ticker.Stop()
+
+	for {
+		if !m.isBusy(id) {
+			return nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
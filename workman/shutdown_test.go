@@ -0,0 +1,119 @@
+package workman
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-per/simpkg/tasks"
+)
+
+// taskWorker runs one task which takes delay
+type taskWorker struct {
+	testWorker
+	delay time.Duration
+}
+
+func (w *taskWorker) RegisterListeners() {
+	w.TaskManager().Add(&tasks.Task{Name: "sleep", Handler: func() error {
+		time.Sleep(w.delay)
+		return nil
+	}})
+}
+
+func (w *taskWorker) Start() {
+	w.TaskManager().Start()
+}
+
+func TestManager_Shutdown(t *testing.T) {
+	tests := []struct {
+		name    string
+		delay   time.Duration
+		timeout time.Duration
+		drained bool
+		wantErr bool
+	}{
+		{"drained", time.Millisecond * 50, time.Second, true, false},
+		{"forced", time.Second, time.Millisecond * 50, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewManager(func() IWorker { return &taskWorker{delay: tt.delay} })
+			m.SetRootPath(t.TempDir())
+			if _, err := m.Add(0, []byte("a")); err != nil {
+				t.Fatalf("Add() error = %v", err)
+			}
+			if err := m.StartWorker("a"); err != nil {
+				t.Fatalf("StartWorker() error = %v", err)
+			}
+			time.Sleep(time.Millisecond * 10)
+
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+			reports, err := m.Shutdown(ctx)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Shutdown() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(reports) != 1 || reports[0].Drained != tt.drained || reports[0].Forced == tt.drained {
+				t.Errorf("Shutdown() reports = %+v, want drained %v", reports, tt.drained)
+			}
+			if err := m.StartWorker("a"); !errors.Is(err, ErrShutdown) {
+				t.Errorf("StartWorker() after Shutdown() error = %v, want %v", err, ErrShutdown)
+			}
+		})
+	}
+}
+
+// parallelWorker runs a slow and a quick task at the same time, quick task ends first
+type parallelWorker struct {
+	testWorker
+	started chan struct{}
+	release chan struct{}
+}
+
+func (w *parallelWorker) RegisterListeners() {
+	w.TaskManager().Add(&tasks.Task{Name: "slow", Handler: func() error {
+		close(w.started)
+		<-w.release
+		return nil
+	}})
+	w.TaskManager().Add(&tasks.Task{Name: "quick", Handler: func() error {
+		<-w.started
+		return nil
+	}})
+}
+
+func (w *parallelWorker) Start() {
+	go func() { _ = w.TaskManager().Once("slow") }()
+	go func() { _ = w.TaskManager().Once("quick") }()
+}
+
+func TestManager_waitIdle(t *testing.T) {
+	release := make(chan struct{})
+	m := NewManager(func() IWorker { return &parallelWorker{started: make(chan struct{}), release: release} })
+	m.SetRootPath(t.TempDir())
+	w, err := m.Add(0, []byte("a"))
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err = m.StartWorker("a"); err != nil {
+		t.Fatalf("StartWorker() error = %v", err)
+	}
+
+	// quick task ends while slow task runs
+	deadline := time.Now().Add(time.Second)
+	for !w.TaskManager().Get("quick").IsSuccess() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	if err = m.waitIdle(ctx, "a"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("waitIdle() with running task error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	close(release)
+	if err = m.waitIdle(context.Background(), "a"); err != nil {
+		t.Errorf("waitIdle() error = %v", err)
+	}
+}
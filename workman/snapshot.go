@@ -0,0 +1,177 @@
+package workman
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/go-per/simpkg/client"
+	"github.com/go-per/simpkg/helpers"
+	"github.com/go-per/simpkg/parse"
+	"github.com/go-per/simpkg/tasks"
+)
+
+// snapshotFile is snapshot file name in worker cache path
+const snapshotFile = "state.json"
+
+// ISnapshotter is implemented by workers which keep their state across restarts
+type ISnapshotter interface {
+	Snapshot() ([]byte, error)
+	Restore(data []byte) error
+}
+
+// WorkerSnapshot is stored state of a worker
+type WorkerSnapshot struct {
+	ID      string                  `json:"id"`
+	Time    time.Time               `json:"time"`
+	Current string                  `json:"current,omitempty"` // current task name
+	Tasks   map[string]tasks.Status `json:"tasks,omitempty"`
+	State   []byte                  `json:"state,omitempty"` // worker snapshot
+}
+
+// SetSnapshotInterval stores snapshots of all workers every interval, zero stops it
+func (m *Manager) SetSnapshotInterval(interval time.Duration) {
+	m.locker.Lock()
+	if m.snapshotDone != nil {
+		close(m.snapshotDone)
+		m.snapshotDone = nil
+	}
+	if interval <= 0 {
+		m.locker.Unlock()
+		return
+	}
+	done := make(chan struct{})
+	m.snapshotDone = done
+	m.locker.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				_ = m.SaveSnapshots()
+			}
+		}
+	}()
+}
+
+// SaveSnapshots stores snapshots of all workers, first error is returned
+func (m *Manager) SaveSnapshots() error {
+	var err error
+	for _, worker := range m.Workers() {
+		if saveErr := m.saveSnapshot(worker); saveErr != nil && err == nil {
+			err = saveErr
+		}
+	}
+	return err
+}
+
+// SaveSnapshot stores snapshot of worker
+func (m *Manager) SaveSnapshot(id string) error {
+	worker, ok := m.Get(id)
+	if !ok {
+		return errors.New("worker not found")
+	}
+	return m.saveSnapshot(worker)
+}
+
+// saveSnapshot stores task statuses, worker state and pending cookies
+func (m *Manager) saveSnapshot(worker IWorker) error {
+	if err := m.saveCookies(worker); err != nil {
+		return err
+	}
+
+	snapshot := WorkerSnapshot{ID: worker.GetID(), Time: time.Now()}
+
+	if tm := worker.TaskManager(); tm != nil {
+		snapshot.Tasks = make(map[string]tasks.Status)
+		for _, task := range tm.Items() {
+			snapshot.Tasks[task.Name] = task.Status()
+		}
+		if current := tm.Current(); current != nil {
+			snapshot.Current = current.Name
+		}
+	}
+
+	if s, ok := worker.(ISnapshotter); ok {
+		state, err := s.Snapshot()
+		if err != nil {
+			return err
+		}
+		snapshot.State = state
+	}
+
+	content, err := parse.Encode(snapshot)
+	if err != nil {
+		return err
+	}
+	return helpers.WriteFileAtomic(m.snapshotPath(snapshot.ID), content)
+}
+
+// saveCookies saves pending cookie changes of worker
+func (m *Manager) saveCookies(worker IWorker) error {
+	c := worker.Client()
+	if c == nil {
+		return nil
+	}
+	if jar, ok := c.GetClient().Jar.(*client.CookieJar); ok {
+		return jar.Flush(context.Background())
+	}
+	return nil
+}
+
+// restoreSnapshot restores stored snapshot of worker, unfinished tasks run again
+func (m *Manager) restoreSnapshot(worker IWorker) error {
+	content, err := os.ReadFile(m.snapshotPath(worker.GetID()))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var snapshot WorkerSnapshot
+	if err = parse.Decode(content, &snapshot); err != nil {
+		return err
+	}
+
+	if tm := worker.TaskManager(); tm != nil {
+		for name, status := range snapshot.Tasks {
+			task := tm.Get(name)
+			if task == nil {
+				continue
+			}
+			// interrupted and failed tasks are retried
+			if status == tasks.StatusSuccess {
+				task.SetStatus(tasks.StatusSuccess)
+			} else {
+				task.SetStatus(tasks.StatusPending)
+			}
+		}
+		if current := tm.Get(snapshot.Current); snapshot.Current != "" && current != nil {
+			tm.SetCurrent(current)
+		}
+	}
+
+	if s, ok := worker.(ISnapshotter); ok && len(snapshot.State) > 0 {
+		return s.Restore(snapshot.State)
+	}
+	return nil
+}
+
+// snapshotPath returns snapshot file path of worker
+func (m *Manager) snapshotPath(id string) string {
+	return filepath.Join(m.workerCachePath(id), snapshotFile)
+}
+
+// workerCachePath returns cache path of worker
+func (m *Manager) workerCachePath(id string) string {
+	return path.Join(m.CachePath(), "_workers", id)
+}
@@ -0,0 +1,74 @@
+package workman
+
+import (
+	"testing"
+
+	"github.com/go-per/simpkg/tasks"
+)
+
+// flowWorker has three tasks and a counter state
+type flowWorker struct {
+	testWorker
+	counter string
+}
+
+func (w *flowWorker) RegisterListeners() {
+	for i, name := range []string{"login", "fetch", "submit"} {
+		w.TaskManager().Add(&tasks.Task{Name: name, Order: i, Handler: func() error { return nil }})
+	}
+}
+
+func (w *flowWorker) Snapshot() ([]byte, error) {
+	return []byte(w.counter), nil
+}
+
+func (w *flowWorker) Restore(data []byte) error {
+	w.counter = string(data)
+	return nil
+}
+
+func TestManager_SaveSnapshot(t *testing.T) {
+	root := t.TempDir()
+	newManager := func() (*Manager, *flowWorker) {
+		m := NewManager(func() IWorker { return &flowWorker{} })
+		m.SetRootPath(root)
+		w, err := m.Add(0, []byte("a"))
+		if err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+		return m, w.(*flowWorker)
+	}
+
+	// login is done and fetch is interrupted
+	m, w := newManager()
+	tm := w.TaskManager()
+	tm.Get("login").SetStatus(tasks.StatusSuccess)
+	tm.Get("fetch").SetStatus(tasks.StatusStart)
+	tm.SetCurrent(tm.Get("fetch"))
+	w.counter = "42"
+	if err := m.SaveSnapshot("a"); err != nil {
+		t.Fatalf("SaveSnapshot() error = %v", err)
+	}
+
+	_, w = newManager()
+	tm = w.TaskManager()
+	if w.counter != "42" {
+		t.Errorf("restored state = %v, want 42", w.counter)
+	}
+	if current := tm.Current(); current == nil || current.Name != "fetch" {
+		t.Errorf("restored current = %v, want fetch", current)
+	}
+	tests := []struct {
+		task   string
+		status tasks.Status
+	}{
+		{"login", tasks.StatusSuccess},
+		{"fetch", tasks.StatusPending},
+		{"submit", tasks.StatusPending},
+	}
+	for _, tt := range tests {
+		if got := tm.Get(tt.task).Status(); got != tt.status {
+			t.Errorf("restored %v status = %v, want %v", tt.task, got, tt.status)
+		}
+	}
+}
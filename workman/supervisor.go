@@ -0,0 +1,375 @@
+package workman
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/go-per/simpkg/tasks"
+)
+
+// WorkerState type
+type WorkerState string
+
+const (
+	WorkerBooting WorkerState = "booting"
+	WorkerRunning WorkerState = "running"
+	WorkerStopped WorkerState = "stopped"
+	WorkerCrashed WorkerState = "crashed"
+	WorkerBackoff WorkerState = "backoff"
+)
+
+// EventWorkerState is dispatched with WorkerOnStateChange on every worker state transition
+const EventWorkerState WorkerEvent = "worker.state"
+
+// RestartPolicy type
+type RestartPolicy string
+
+const (
+	RestartAlways    RestartPolicy = "always"
+	RestartOnFailure RestartPolicy = "on_failure"
+	RestartNever     RestartPolicy = "never"
+)
+
+// IRunner is implemented by workers which block while running, Run must return when ctx is done.
+// Workers without Run are started by Start and stopped by Stop, panics of Start and of task
+// handlers crash the worker, goroutines which Start runs by itself are not recovered
+type IRunner interface {
+	Run(ctx context.Context) error
+}
+
+// SupervisorPolicy struct
+type SupervisorPolicy struct {
+	Restart        RestartPolicy `json:"restart"`
+	InitialBackoff time.Duration `json:"initial_backoff"`
+	MaxBackoff     time.Duration `json:"max_backoff"`
+	MaxRestarts    int           `json:"max_restarts"` // zero is unlimited
+	Window         time.Duration `json:"window"`       // max restarts window
+}
+
+// WorkerOnStateChange struct
+type WorkerOnStateChange struct {
+	From     WorkerState
+	To       WorkerState
+	Err      error
+	Restarts int
+	Worker   IWorker
+}
+
+// supervised is the supervision state of one worker
+type supervised struct {
+	worker   IWorker
+	state    WorkerState
+	restarts []time.Time
+	cancel   context.CancelFunc
+	done     chan struct{}
+	failures chan error
+	running  map[*tasks.Task]bool // tasks in flight
+}
+
+// DefaultSupervisorPolicy returns default policy, restart on failure with backoff from 1s to 1m
+// and at most 5 restarts in 10 minutes
+func DefaultSupervisorPolicy() SupervisorPolicy {
+	return SupervisorPolicy{
+		Restart:        RestartOnFailure,
+		InitialBackoff: time.Second,
+		MaxBackoff:     time.Minute,
+		MaxRestarts:    5,
+		Window:         time.Minute * 10,
+	}
+}
+
+// SetSupervisorPolicy sets restart policy of workers
+func (m *Manager) SetSupervisorPolicy(policy SupervisorPolicy) {
+	m.supervisorLocker.Lock()
+	m.supervisorPolicy = policy
+	m.supervisorLocker.Unlock()
+}
+
+// WorkerState returns supervised state of worker
+func (m *Manager) WorkerState(id string) (WorkerState, bool) {
+	m.supervisorLocker.Lock()
+	defer m.supervisorLocker.Unlock()
+
+	s, ok := m.supervised[id]
+	if !ok {
+		return "", false
+	}
+	return s.state, true
+}
+
+// WorkerStates returns supervised states of workers
+func (m *Manager) WorkerStates() map[string]WorkerState {
+	m.supervisorLocker.Lock()
+	defer m.supervisorLocker.Unlock()
+
+	states := make(map[string]WorkerState, len(m.supervised))
+	for id, s := range m.supervised {
+		states[id] = s.state
+	}
+	return states
+}
+
+// StartWorker starts worker under supervision
+func (m *Manager) StartWorker(id string) error {
+	if m.IsShutdown() {
+		return ErrShutdown
+	}
+
+	worker, ok := m.Get(id)
+	if !ok {
+		return fmt.Errorf("worker not found [%v]", id)
+	}
+
+	m.supervisorLocker.Lock()
+	s := m.supervision(worker)
+	if s.cancel != nil {
+		m.supervisorLocker.Unlock()
+		return fmt.Errorf("worker is already started [%v]", id)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done = make(chan struct{})
+	s.restarts = nil
+	policy := m.supervisorPolicy
+	m.supervisorLocker.Unlock()
+
+	go m.supervise(ctx, s, policy)
+	return nil
+}
+
+// StopWorker stops supervised worker and waits until it is stopped
+func (m *Manager) StopWorker(id string) error {
+	return m.stopWorker(context.Background(), id)
+}
+
+// stopWorker stops supervised worker and waits until it is stopped or ctx is done
+func (m *Manager) stopWorker(ctx context.Context, id string) error {
+	m.supervisorLocker.Lock()
+	s, ok := m.supervised[id]
+	if !ok || s.cancel == nil {
+		m.supervisorLocker.Unlock()
+		return fmt.Errorf("worker is not started [%v]", id)
+	}
+	cancel, done := s.cancel, s.done
+	m.supervisorLocker.Unlock()
+
+	cancel()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// StartAll starts all workers under supervision
+func (m *Manager) StartAll() {
+	for _, worker := range m.Workers() {
+		_ = m.StartWorker(worker.GetID())
+	}
+}
+
+// StopAll stops all supervised workers
+func (m *Manager) StopAll() {
+	for _, worker := range m.Workers() {
+		_ = m.StopWorker(worker.GetID())
+	}
+}
+
+// supervision returns supervision state of worker, caller must hold the supervisor lock
+func (m *Manager) supervision(worker IWorker) *supervised {
+	id := worker.GetID()
+	if s, ok := m.supervised[id]; ok && s.worker == worker {
+		return s
+	}
+
+	s := &supervised{worker: worker, state: WorkerStopped, failures: make(chan error, 1), running: make(map[*tasks.Task]bool)}
+	m.supervised[id] = s
+
+	// track running tasks, task failures crash the worker
+	if tm := worker.TaskManager(); tm != nil {
+		tm.OnStatusChange(func(task *tasks.Task) {
+			status := task.Status()
+			m.supervisorLocker.Lock()
+			if status == tasks.StatusStart || status == tasks.StatusRetry {
+				s.running[task] = true
+			} else {
+				delete(s.running, task)
+			}
+			m.supervisorLocker.Unlock()
+
+			if status != tasks.StatusFail {
+				return
+			}
+			err := task.Error()
+			if err == nil {
+				err = fmt.Errorf("task failed [%v]", task.Name)
+			}
+			select {
+			case s.failures <- err:
+			default:
+			}
+		})
+	}
+	return s
+}
+
+// setWorkerState changes worker state and dispatches event
+func (m *Manager) setWorkerState(worker IWorker, state WorkerState, err error) {
+	m.supervisorLocker.Lock()
+	s := m.supervision(worker)
+	m.supervisorLocker.Unlock()
+
+	m.setState(s, state, err)
+}
+
+// setState changes supervised state and dispatches event
+func (m *Manager) setState(s *supervised, state WorkerState, err error) {
+	m.supervisorLocker.Lock()
+	from := s.state
+	s.state = state
+	restarts := len(s.restarts)
+	m.supervisorLocker.Unlock()
+
+	// stopped worker gives back its proxy
+	if state == WorkerStopped || state == WorkerCrashed {
+		m.releaseWorkerProxy(s.worker.GetID())
+	}
+
+	m.eventbus.Dispatch(string(EventWorkerState), WorkerOnStateChange{
+		From:     from,
+		To:       state,
+		Err:      err,
+		Restarts: restarts,
+		Worker:   s.worker,
+	})
+}
+
+// forgetWorker stops supervision of removed worker, reports whether worker was stopped
+func (m *Manager) forgetWorker(id string) bool {
+	m.supervisorLocker.Lock()
+	s, ok := m.supervised[id]
+	delete(m.supervised, id)
+	var cancel context.CancelFunc
+	var done chan struct{}
+	if ok {
+		cancel, done = s.cancel, s.done
+	}
+	m.supervisorLocker.Unlock()
+
+	if cancel == nil {
+		return false
+	}
+	cancel()
+	<-done
+	return true
+}
+
+// supervise runs worker and restarts it by policy until ctx is done
+func (m *Manager) supervise(ctx context.Context, s *supervised, policy SupervisorPolicy) {
+	defer func() {
+		m.supervisorLocker.Lock()
+		s.cancel = nil
+		m.supervisorLocker.Unlock()
+		close(s.done)
+	}()
+
+	backoff := policy.InitialBackoff
+	for {
+		// drop failures of the previous run
+		select {
+		case <-s.failures:
+		default:
+		}
+
+		m.setState(s, WorkerRunning, nil)
+		started := time.Now()
+		err := m.run(ctx, s)
+		if ctx.Err() != nil {
+			m.setState(s, WorkerStopped, nil)
+			return
+		}
+
+		// crashed worker keeps its state when it is not restarted
+		if err != nil {
+			m.setState(s, WorkerCrashed, err)
+			if policy.Restart == RestartNever {
+				return
+			}
+		} else if policy.Restart != RestartAlways {
+			m.setState(s, WorkerStopped, nil)
+			return
+		}
+
+		// give up when restarted too often
+		now := time.Now()
+		m.supervisorLocker.Lock()
+		restarts := s.restarts[:0]
+		for _, t := range s.restarts {
+			if policy.Window <= 0 || now.Sub(t) < policy.Window {
+				restarts = append(restarts, t)
+			}
+		}
+		s.restarts = append(restarts, now)
+		exceeded := policy.MaxRestarts > 0 && len(s.restarts) > policy.MaxRestarts
+		m.supervisorLocker.Unlock()
+		if exceeded {
+			m.setState(s, WorkerCrashed, errors.New("max restarts exceeded"))
+			return
+		}
+
+		// a worker which ran longer than max backoff restarts with initial backoff
+		if policy.MaxBackoff > 0 && time.Since(started) > policy.MaxBackoff {
+			backoff = policy.InitialBackoff
+		}
+		m.setState(s, WorkerBackoff, err)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			m.setState(s, WorkerStopped, nil)
+			return
+		}
+		if backoff *= 2; policy.MaxBackoff > 0 && backoff > policy.MaxBackoff {
+			backoff = policy.MaxBackoff
+		}
+	}
+}
+
+// run runs worker until it returns, fails or ctx is done, panics are returned as error,
+// cookies of the run are saved when it ends
+func (m *Manager) run(ctx context.Context, s *supervised) error {
+	defer func() { _ = m.saveCookies(s.worker) }()
+
+	if runner, ok := s.worker.(IRunner); ok {
+		return safeCall(func() error { return runner.Run(ctx) })
+	}
+
+	if err := safeCall(func() error { s.worker.Start(); return nil }); err != nil {
+		_ = safeCall(func() error { s.worker.Stop(); return nil })
+		return err
+	}
+
+	var err error
+	select {
+	case <-ctx.Done():
+	case err = <-s.failures:
+	}
+	if stopErr := safeCall(func() error { s.worker.Stop(); return nil }); err == nil {
+		err = stopErr
+	}
+	return err
+}
+
+// safeCall calls fn and returns panic as error
+func safeCall(fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("worker panic: %v\n%s", r, debug.Stack())
+		}
+	}()
+
+	return fn()
+}
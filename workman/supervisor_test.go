@@ -0,0 +1,112 @@
+package workman
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-per/simpkg/tasks"
+)
+
+// crashWorker panics on every run
+type crashWorker struct {
+	testWorker
+	runs int32
+}
+
+func (w *crashWorker) Run(ctx context.Context) error {
+	atomic.AddInt32(&w.runs, 1)
+	panic("crash")
+}
+
+// panicTaskWorker panics in a task handler which Start runs in background
+type panicTaskWorker struct {
+	testWorker
+}
+
+func (w *panicTaskWorker) RegisterListeners() {
+	w.TaskManager().Add(&tasks.Task{Name: "panic", Handler: func() error { panic("task crash") }})
+}
+
+func (w *panicTaskWorker) Start() {
+	go func() { _ = w.TaskManager().Once("panic") }()
+}
+
+func TestManager_StartWorker(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy SupervisorPolicy
+		runs   int32
+		state  WorkerState
+	}{
+		{"never", SupervisorPolicy{Restart: RestartNever}, 1, WorkerCrashed},
+		{"max restarts", SupervisorPolicy{Restart: RestartOnFailure, InitialBackoff: time.Millisecond, MaxRestarts: 2}, 3, WorkerCrashed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewManager(func() IWorker { return &crashWorker{} })
+			m.SetRootPath(t.TempDir())
+			m.SetSupervisorPolicy(tt.policy)
+			if _, err := m.Add(0, []byte("a")); err != nil {
+				t.Fatalf("Add() error = %v", err)
+			}
+			if err := m.StartWorker("a"); err != nil {
+				t.Fatalf("StartWorker() error = %v", err)
+			}
+
+			deadline := time.Now().Add(time.Second)
+			w, _ := m.Get("a")
+			for time.Now().Before(deadline) {
+				state, _ := m.WorkerState("a")
+				if state == tt.state && atomic.LoadInt32(&w.(*crashWorker).runs) == tt.runs {
+					return
+				}
+				time.Sleep(time.Millisecond)
+			}
+			state, _ := m.WorkerState("a")
+			t.Errorf("state = %v runs = %v, want %v runs = %v", state, atomic.LoadInt32(&w.(*crashWorker).runs), tt.state, tt.runs)
+		})
+	}
+}
+
+// waitState waits up to a second until worker is in state and returns the last state
+func waitState(m *Manager, id string, want WorkerState) WorkerState {
+	deadline := time.Now().Add(time.Second)
+	state, _ := m.WorkerState(id)
+	for state != want && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+		state, _ = m.WorkerState(id)
+	}
+	return state
+}
+
+func TestManager_StartWorker_TaskPanic(t *testing.T) {
+	m := NewManager(func() IWorker { return &panicTaskWorker{} })
+	m.SetRootPath(t.TempDir())
+	m.SetSupervisorPolicy(SupervisorPolicy{Restart: RestartNever})
+	if _, err := m.Add(0, []byte("a")); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	var event WorkerOnStateChange
+	var locker sync.Mutex
+	m.Eventbus().Subscribe(string(EventWorkerState), func(v ...any) {
+		locker.Lock()
+		event = v[0].(WorkerOnStateChange)
+		locker.Unlock()
+	})
+	if err := m.StartWorker("a"); err != nil {
+		t.Fatalf("StartWorker() error = %v", err)
+	}
+	if state := waitState(m, "a", WorkerCrashed); state != WorkerCrashed {
+		t.Fatalf("WorkerState(a) = %v, want %v", state, WorkerCrashed)
+	}
+	locker.Lock()
+	defer locker.Unlock()
+	if event.Err == nil || !strings.Contains(event.Err.Error(), "task crash") {
+		t.Errorf("crash error = %v, want task panic", event.Err)
+	}
+}
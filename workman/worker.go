@@ -2,11 +2,18 @@ package workman
 
 import (
 	"context"
-	"github.com/go-per/simpkg/logger"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
 
 	"github.com/go-per/simpkg/cache"
-	"github.com/go-per/simpkg/random"
+	"github.com/go-per/simpkg/client"
+	"github.com/go-per/simpkg/logger"
 	"github.com/go-per/simpkg/tasks"
+	"github.com/go-per/simpkg/timerange"
 	"github.com/imroc/req/v3"
 )
 
@@ -31,8 +38,21 @@ type IWorker interface {
 	Stop()
 }
 
+// IClientPreset is implemented by workers which choose their client preset
+type IClientPreset interface {
+	ClientPreset() client.Preset
+}
+
+// IPausable is implemented by workers which can be paused, e.g. while upstream circuit is open
+type IPausable interface {
+	Pause(reason string)
+	Resume()
+	IsPaused() bool
+}
+
 // Worker struct
 type Worker struct {
+	id          string
 	index       int
 	cache       cache.ICache
 	taskManager tasks.IManager
@@ -40,10 +60,44 @@ type Worker struct {
 	ctx         context.Context
 	logger      logger.ILogger
 	filePath    string
+	preset      client.Preset
+	weight      int
+	paused      bool
+	pauseReason string
+	proxy       string
+	tags        []string
+	schedule    *timerange.Schedule
+	locker      sync.RWMutex
+}
+
+// WorkerDetails is details of base worker
+type WorkerDetails struct {
+	Proxy string `json:"proxy,omitempty"`
 }
 
-// Init initialize worker
-func (w *Worker) Init([]byte, ...string) (string, error) { return "", nil }
+// Init initialize worker, id is the id field of config or the config file name
+func (w *Worker) Init(data []byte, fileName ...string) (string, error) {
+	id := ConfigID(data, fileName...)
+	if id == "" {
+		return "", errors.New("worker config has no id")
+	}
+
+	schedule, err := ConfigSchedule(data)
+	if err != nil {
+		return "", err
+	}
+	weight, err := ConfigWeight(data)
+	if err != nil {
+		return "", err
+	}
+
+	w.SetID(id)
+	w.SetTags(ConfigTags(data)...)
+	w.SetSchedule(schedule)
+	w.SetWeight(weight)
+	w.SetClientPreset(ConfigClientPreset(data))
+	return id, nil
+}
 
 // RegisterListeners trigger before boot
 func (w *Worker) RegisterListeners() {}
@@ -61,14 +115,19 @@ func (w *Worker) Index() int {
 	return w.index
 }
 
+// SetID sets worker id, workers which override Init should set it
+func (w *Worker) SetID(id string) {
+	w.id = id
+}
+
 // GetID returns worker id
 func (w *Worker) GetID() string {
-	return random.String(8)
+	return w.id
 }
 
 // GetDetails returns worker details
 func (w *Worker) GetDetails() any {
-	return nil
+	return WorkerDetails{Proxy: w.Proxy()}
 }
 
 // SetCache sets cache instance
@@ -106,13 +165,197 @@ func (w *Worker) SetClient(client *req.Client) {
 	w.client = client
 }
 
+// SetClientPreset sets client preset used by manager, e.g. from worker config
+func (w *Worker) SetClientPreset(preset client.Preset) {
+	w.locker.Lock()
+	w.preset = preset
+	w.locker.Unlock()
+}
+
+// ClientPreset returns client preset
+func (w *Worker) ClientPreset() client.Preset {
+	w.locker.RLock()
+	defer w.locker.RUnlock()
+
+	return w.preset
+}
+
+// SetWeight sets selection weight, e.g. from worker config
+func (w *Worker) SetWeight(weight int) {
+	w.locker.Lock()
+	w.weight = weight
+	w.locker.Unlock()
+}
+
+// Weight returns selection weight
+func (w *Worker) Weight() int {
+	w.locker.RLock()
+	defer w.locker.RUnlock()
+
+	return w.weight
+}
+
 // Client returns client instance
 func (w *Worker) Client() *req.Client {
 	return w.client
 }
 
+// Pause pauses worker, worker loops should check IsPaused
+func (w *Worker) Pause(reason string) {
+	w.locker.Lock()
+	w.paused = true
+	w.pauseReason = reason
+	w.locker.Unlock()
+}
+
+// Resume resumes paused worker
+func (w *Worker) Resume() {
+	w.locker.Lock()
+	w.paused = false
+	w.pauseReason = ""
+	w.locker.Unlock()
+}
+
+// IsPaused returns pause status
+func (w *Worker) IsPaused() bool {
+	w.locker.RLock()
+	defer w.locker.RUnlock()
+
+	return w.paused
+}
+
+// PauseReason returns pause reason
+func (w *Worker) PauseReason() string {
+	w.locker.RLock()
+	defer w.locker.RUnlock()
+
+	return w.pauseReason
+}
+
+// SetProxy sets current proxy, it is set by manager proxy policy
+func (w *Worker) SetProxy(proxy string) {
+	w.locker.Lock()
+	w.proxy = proxy
+	w.locker.Unlock()
+}
+
+// Proxy returns current proxy
+func (w *Worker) Proxy() string {
+	w.locker.RLock()
+	defer w.locker.RUnlock()
+
+	return w.proxy
+}
+
+// SetTags sets worker tags, e.g. target site and account tier
+func (w *Worker) SetTags(tags ...string) {
+	w.locker.Lock()
+	w.tags = normalizeTags(tags)
+	w.locker.Unlock()
+}
+
+// Tags returns worker tags
+func (w *Worker) Tags() []string {
+	w.locker.RLock()
+	defer w.locker.RUnlock()
+
+	return append([]string{}, w.tags...)
+}
+
+// SetSchedule sets run windows of worker, nil runs worker without schedule
+func (w *Worker) SetSchedule(schedule *timerange.Schedule) {
+	w.locker.Lock()
+	w.schedule = schedule
+	w.locker.Unlock()
+}
+
+// Schedule returns run windows of worker
+func (w *Worker) Schedule() *timerange.Schedule {
+	w.locker.RLock()
+	defer w.locker.RUnlock()
+
+	return w.schedule
+}
+
 // Start worker
 func (w *Worker) Start() {}
 
 // Stop worker
 func (w *Worker) Stop() {}
+
+// ConfigID returns id field of json config, or config file name without extension
+func ConfigID(data []byte, fileName ...string) string {
+	var config struct {
+		ID json.RawMessage `json:"id"`
+	}
+	if err := json.Unmarshal(data, &config); err == nil && len(config.ID) > 0 {
+		var id string
+		if err = json.Unmarshal(config.ID, &id); err != nil {
+			// numeric id
+			id = string(config.ID)
+		}
+		if id = strings.TrimSpace(id); id != "" && id != "null" {
+			return id
+		}
+	}
+
+	if len(fileName) > 0 && fileName[0] != "" {
+		name := filepath.Base(fileName[0])
+		return strings.TrimSuffix(name, filepath.Ext(name))
+	}
+	return ""
+}
+
+// ConfigTags returns tags field and group field of json config
+func ConfigTags(data []byte) []string {
+	var config struct {
+		Tags  []string `json:"tags"`
+		Group string   `json:"group"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil
+	}
+
+	return normalizeTags(append(config.Tags, config.Group))
+}
+
+// ConfigSchedule returns schedule field of json config, nil when config has no schedule
+func ConfigSchedule(data []byte) (*timerange.Schedule, error) {
+	var config struct {
+		Schedule json.RawMessage `json:"schedule"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil || len(config.Schedule) == 0 || string(config.Schedule) == "null" {
+		// config format is validated by Init of worker
+		return nil, nil
+	}
+	return timerange.ParseSchedule(config.Schedule)
+}
+
+// ConfigClientPreset returns preset field of json config, empty uses manager preset
+func ConfigClientPreset(data []byte) client.Preset {
+	var config struct {
+		Preset string `json:"preset"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return ""
+	}
+
+	return client.Preset(strings.TrimSpace(config.Preset))
+}
+
+// ConfigWeight returns weight field of json config, zero when config has no weight
+func ConfigWeight(data []byte) (int, error) {
+	var config struct {
+		Weight json.RawMessage `json:"weight"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil || len(config.Weight) == 0 || string(config.Weight) == "null" {
+		// config format is validated by Init of worker
+		return 0, nil
+	}
+
+	var weight int
+	if err := json.Unmarshal(config.Weight, &weight); err != nil || weight < 0 {
+		return 0, fmt.Errorf("invalid worker weight %s", config.Weight)
+	}
+	return weight, nil
+}